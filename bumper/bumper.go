@@ -1,11 +1,13 @@
 package bumper
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"regexp"
-	"sort"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/screwdriver-cd/gitversion/git"
@@ -16,20 +18,49 @@ import (
 
 type (
 	bumpOptions struct {
-		prefix string
-		field  Field
-		merged bool
-		dryrun bool
+		prefix        string
+		field         Field
+		merged        bool
+		dryrun        bool
+		preID         string
+		base          Field
+		requireHead   bool
+		buildMetadata string
+		annotate      bool
+		message       string
+		sign          bool
+		signingKey    string
+		signFormat    string
+		push          bool
+		remote        string
+		fetch         bool
+		requireTags   bool
+		ref           string
+		deepen        Deepen
 	}
 	BumpOption func(*bumpOptions)
 
 	Bumper interface {
-		Bump(...BumpOption) error
-		LatestVersion(prefix string, merged bool) (v version.Version, err error)
-		Versions(prefix string, merged bool) (version.List, error)
+		Bump(ctx context.Context, options ...BumpOption) error
+		LatestVersion(ctx context.Context, prefix, ref string, merged bool) (v version.Version, err error)
+		Versions(ctx context.Context, prefix, ref string, merged bool) (version.List, error)
+		TaggedVersions(ctx context.Context, prefix, ref string, merged bool) ([]TaggedVersion, error)
+		Parse(prefix, tag string) (version.Version, error)
+		BuildMetadata(ctx context.Context, template, ref string) (string, error)
+		Lint(ctx context.Context, prefix, ref string, merged bool) ([]LintIssue, error)
+		VerifyTag(ctx context.Context, tag string) error
+	}
+	// TaggedVersion is the version of a tag, whose name may not be the
+	// canonical format of the version (e.g. 1.0 for 1.0.0)
+	TaggedVersion struct {
+		Tag     string
+		Version version.Version
 	}
 	DefaultBumper struct {
 		Git git.Git
+		// Scheme determines how tags are parsed, ordered and bumped; the
+		// version.DefaultScheme is used when it is nil
+		Scheme version.Scheme
 	}
 )
 
@@ -38,6 +69,9 @@ var (
 
 	defaultBumpOptions = []BumpOption{
 		WithField(FieldAuto),
+		WithRemote("origin"),
+		WithRef("HEAD"),
+		WithDeepen(DeepenNever),
 	}
 )
 
@@ -75,81 +109,267 @@ func WithMerged(merged bool) BumpOption {
 	}
 }
 
+// WithRef tags the commit a ref (e.g. a branch or a SHA) points at instead
+// of HEAD; merged tags are the ones merged into it
+func WithRef(ref string) BumpOption {
+	return func(options *bumpOptions) {
+		options.ref = ref
+	}
+}
+
 func WithDryRun(dryrun bool) BumpOption {
 	return func(options *bumpOptions) {
 		options.dryrun = dryrun
 	}
 }
 
+// WithPreID numbers prereleases with an identifier (e.g. rc gives
+// 1.3.0-rc.1, 1.3.0-rc.2, ...) instead of using the commit SHA
+func WithPreID(preID string) BumpOption {
+	return func(options *bumpOptions) {
+		options.preID = preID
+	}
+}
+
+// WithBase sets the field bumped on the latest release to start the
+// version of a numbered prerelease; it requires WithPreID
+func WithBase(base Field) BumpOption {
+	return func(options *bumpOptions) {
+		options.base = base
+	}
+}
+
+// WithRequireHead makes the release field fail unless the ref (HEAD by
+// default) is the commit tagged with the latest prerelease
+func WithRequireHead(requireHead bool) BumpOption {
+	return func(options *bumpOptions) {
+		options.requireHead = requireHead
+	}
+}
+
+// WithBuildMetadata sets the build metadata of the new version from a
+// template, see BuildInfo
+func WithBuildMetadata(template string) BumpOption {
+	return func(options *bumpOptions) {
+		options.buildMetadata = template
+	}
+}
+
+// WithAnnotate creates an annotated tag
+func WithAnnotate(annotate bool) BumpOption {
+	return func(options *bumpOptions) {
+		options.annotate = annotate
+	}
+}
+
+// WithMessage sets the message of an annotated tag from a template, see
+// TagInfo; a message implies WithAnnotate
+func WithMessage(template string) BumpOption {
+	return func(options *bumpOptions) {
+		options.message = template
+	}
+}
+
+// WithSign creates a signed tag
+func WithSign(sign bool) BumpOption {
+	return func(options *bumpOptions) {
+		options.sign = sign
+	}
+}
+
+// WithSigningKey signs the tag with a key instead of the default one
+func WithSigningKey(key string) BumpOption {
+	return func(options *bumpOptions) {
+		options.signingKey = key
+	}
+}
+
+// WithSignFormat sets the signature format (openpgp, x509 or ssh) instead
+// of the gpg.format git config
+func WithSignFormat(format string) BumpOption {
+	return func(options *bumpOptions) {
+		options.signFormat = format
+	}
+}
+
+// WithPush pushes the new tag to the remote after creating it
+func WithPush(push bool) BumpOption {
+	return func(options *bumpOptions) {
+		options.push = push
+	}
+}
+
+// WithRemote sets the remote tags are fetched from and the new tag is
+// pushed to, origin by default
+func WithRemote(remote string) BumpOption {
+	return func(options *bumpOptions) {
+		options.remote = remote
+	}
+}
+
+// WithFetch fetches the tags of the remote before finding the latest
+// version
+func WithFetch(fetch bool) BumpOption {
+	return func(options *bumpOptions) {
+		options.fetch = fetch
+	}
+}
+
+// WithRequireTags fails the bump when there are no version tags instead of
+// starting from the zero version
+func WithRequireTags(requireTags bool) BumpOption {
+	return func(options *bumpOptions) {
+		options.requireTags = requireTags
+	}
+}
+
+// WithDeepen sets whether the history of a shallow clone is fetched from the
+// remote until a version tag is found; without it the bump fails
+func WithDeepen(deepen Deepen) BumpOption {
+	return func(options *bumpOptions) {
+		options.deepen = deepen
+	}
+}
+
 var (
 	_ Bumper = &DefaultBumper{}
 
-	errNoVersionTags = errors.New("no valid version tags found")
+	errNoVersionTags    = errors.New("no valid version tags found")
+	errBaseWithoutPreID = errors.New("a prerelease base requires a prerelease identifier")
 )
 
-func (d *DefaultBumper) Bump(options ...BumpOption) error {
+func (d *DefaultBumper) Bump(ctx context.Context, options ...BumpOption) error {
 	opts := newBumpOptions(options...)
 	field := opts.field
+	scheme := d.scheme()
+
+	if opts.base != "" && opts.preID == "" {
+		return errBaseWithoutPreID
+	}
+	if opts.buildMetadata != "" {
+		if err := version.CheckBuildMetadata(scheme); err != nil {
+			return err
+		}
+	}
 
-	v, err := d.LatestVersion(opts.prefix, opts.merged)
+	var v version.Version
+	var previous string
+	if opts.fetch {
+		if err := d.Git.FetchTags(ctx, opts.remote); err != nil {
+			return err
+		}
+	}
+	tagged, err := d.deepenedVersions(ctx, opts)
+	var versions version.List
+	var latest TaggedVersion
 	if err != nil {
-		if err == errNoVersionTags {
+		if err == errNoVersionTags && opts.requireTags {
+			return err
+		} else if err == errNoVersionTags {
 			s := err.Error()
 			s = fmt.Sprintf("%s%s", strings.ToUpper(string(s[0])), s[1:])
-			log.Printf("WARNING: %v. Using %v", s, v)
+			log.Printf("WARNING: %v. Using %v", s, scheme.Format(v))
 		} else {
 			return fmt.Errorf("getting latest version %v: %w", v, err)
 		}
+	} else {
+		versions = versionsOf(tagged)
+		latest = d.latest(tagged)
+		v, previous = latest.Version, latest.Tag
 	}
 
-	log.Printf("Bumping %v for version %v", field, v)
 	if field == FieldAuto {
-		// If this commit already has a tag, patch
-		if tag, _ := d.Git.Tagged(); tag {
+		// If this commit is already released, its version is the result
+		released, err := d.releasedTag(ctx, opts.prefix, opts.ref)
+		if err != nil {
+			return err
+		} else if released != "" {
+			log.Printf("%s is already tagged %s", opts.ref, released)
+			_, err = fmt.Println(released)
+			return err
+		}
+	}
+
+	log.Printf("Bumping %v for version %v", field, scheme.Format(v))
+	if field == FieldAuto {
+		// Get commit message and find any reference
+		cm, mesErr := d.Git.LastCommitMessage(ctx, opts.ref)
+		if mesErr != nil {
+			return fmt.Errorf("determing auto patch %w", mesErr)
+		}
+		re := regexp.MustCompile(`(?i)\[(major|minor|patch|revision|prerelease)( bump)?\]`)
+		m := re.FindStringSubmatch(cm)
+		if len(m) == 0 {
 			field = FieldPatch
 		} else {
-			// Get commit message and find any reference
-			cm, mesErr := d.Git.LastCommitMessage()
-			if mesErr != nil {
-				return fmt.Errorf("determing auto patch %w", mesErr)
-			}
-			re := regexp.MustCompile(`(?i)\[(major|minor|patch|prerelease)( bump)?\]`)
-			m := re.FindStringSubmatch(cm)
-			if len(m) == 0 {
-				field = FieldPatch
-			} else {
-				if field, err = ParseField(strings.ToLower(m[MatchField])); err != nil {
-					return err
-				}
+			if field, err = ParseField(strings.ToLower(m[MatchField])); err != nil {
+				return err
 			}
 		}
 	}
 
-	switch field {
-	default:
-		return errors.New("unknown field type")
-	case FieldMajor:
-		v.Major++
-		v.Minor = 0
-		v.Patch = 0
-	case FieldMinor:
-		v.Minor++
-		v.Patch = 0
-	case FieldPatch:
-		v.Patch++
-	case FieldPrerelease:
-		commit, cerr := d.Git.LastCommit(true)
+	if field == FieldPrerelease && opts.preID != "" {
+		if v, err = d.nextPreRelease(v, versions, opts.preID, opts.base); err != nil {
+			return err
+		}
+	} else if field == FieldRelease {
+		if v, err = d.promote(ctx, latest, opts.ref, opts.requireHead); err != nil {
+			return err
+		}
+	} else if field == FieldPrerelease {
+		commit, cerr := d.Git.LastCommit(ctx, opts.ref, true)
 		if cerr != nil {
 			return fmt.Errorf("getting current commit sha %w", cerr)
 		}
-		v.PreRelease = commit
+		// the g prefix keeps a SHA of only digits from being a numeric
+		// identifier, which must not have leading zeros
+		v.PreRelease, v.Build = "g"+commit, ""
+	} else if v, err = scheme.Bump(v, field.String()); err != nil {
+		return err
+	}
+
+	if opts.buildMetadata != "" {
+		if v.Build, err = d.BuildMetadata(ctx, opts.buildMetadata, opts.ref); err != nil {
+			return err
+		}
+	}
+
+	newTag := fmt.Sprintf("%s%s", opts.prefix, scheme.Format(v))
+	var tagOptions []git.TagOption
+	sign := opts.sign || opts.signingKey != ""
+	if sign {
+		tagOptions = append(tagOptions, git.WithSign(opts.signingKey))
+		if opts.signFormat != "" {
+			tagOptions = append(tagOptions, git.WithSignFormat(opts.signFormat))
+		}
+	}
+	if opts.annotate || opts.message != "" || sign {
+		message, err := d.tagMessage(ctx, opts.message, opts.ref, TagInfo{
+			Tag:      newTag,
+			Version:  scheme.Format(v),
+			Previous: previous,
+			Field:    field.String(),
+		})
+		if err != nil {
+			return err
+		}
+		tagOptions = append(tagOptions, git.WithMessage(message))
+		log.Printf("Tag message:\n%s", message)
 	}
 
-	newTag := fmt.Sprintf("%s%s", opts.prefix, v)
 	if opts.dryrun {
 		log.Print("Dryrun; not git tagging")
-	} else if err = d.Git.Tag(newTag); err != nil {
-		return fmt.Errorf("creating new tag %v: %w", v, err)
+	} else if err = d.Git.Tag(ctx, newTag, opts.ref, tagOptions...); err != nil {
+		return fmt.Errorf("creating new tag %v: %w", newTag, err)
+	} else if opts.push {
+		if err = d.Git.Push(ctx, opts.remote, newTag); err != nil {
+			// Don't leave a local tag behind for a version that isn't
+			// published, even when the push was cancelled
+			if derr := d.Git.DeleteTag(context.WithoutCancel(ctx), newTag); derr != nil {
+				log.Printf("WARNING: %v", derr)
+			}
+			return err
+		}
 	}
 
 	// Print out the new tag
@@ -157,19 +377,54 @@ func (d *DefaultBumper) Bump(options ...BumpOption) error {
 	return err
 }
 
-func (d *DefaultBumper) LatestVersion(prefix string, merged bool) (v version.Version, err error) {
-	versions, err := d.Versions(prefix, merged)
+// releasedTag returns the newest release tag with the prefix that points at
+// the commit of ref, or an empty string when there is none. Prereleases
+// don't count, as the commit is still to be released.
+func (d *DefaultBumper) releasedTag(ctx context.Context, prefix, ref string) (string, error) {
+	tags, err := d.Git.TagsPointingAt(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("checking if %s is released: %w", ref, err)
+	}
+
+	var released string
+	var latest version.Version
+	for _, tag := range tags {
+		if len(tag) <= len(prefix) || tag[:len(prefix)] != prefix {
+			continue
+		}
+		v, err := d.Parse(prefix, tag)
+		if err != nil || v.PreRelease != "" {
+			continue
+		}
+		if released == "" || d.scheme().Compare(v, latest) > 0 {
+			released, latest = tag, v
+		}
+	}
+	return released, nil
+}
+
+func (d *DefaultBumper) LatestVersion(ctx context.Context, prefix, ref string, merged bool) (v version.Version, err error) {
+	versions, err := d.Versions(ctx, prefix, ref, merged)
 	if err != nil {
 		return v, err
 	}
 
-	sort.Sort(sort.Reverse(&versions))
-	return versions[0], err
+	return slices.MaxFunc(versions, d.scheme().Compare), nil
+}
+
+func (d *DefaultBumper) Versions(ctx context.Context, prefix, ref string, merged bool) (version.List, error) {
+	tagged, err := d.TaggedVersions(ctx, prefix, ref, merged)
+	if err != nil {
+		return nil, err
+	}
+	return versionsOf(tagged), nil
 }
 
-func (d *DefaultBumper) Versions(prefix string, merged bool) (version.List, error) {
-	versions := version.List{}
-	tags, err := d.Git.Tags(merged)
+// TaggedVersions returns the versions of the tags with the prefix, along
+// with the names of their tags
+func (d *DefaultBumper) TaggedVersions(ctx context.Context, prefix, ref string, merged bool) ([]TaggedVersion, error) {
+	var versions []TaggedVersion
+	tags, err := d.Git.Tags(ctx, ref, merged)
 	if err != nil {
 		return nil, fmt.Errorf("fetching git tags: %w", err)
 	}
@@ -178,16 +433,140 @@ func (d *DefaultBumper) Versions(prefix string, merged bool) (version.List, erro
 		if len(tag) <= len(prefix) || tag[:len(prefix)] != prefix {
 			continue
 		}
-		tag = tag[len(prefix):]
-		v, err := version.FromString(tag)
+		v, err := d.Parse(prefix, tag)
 		if err != nil {
 			continue
 		}
-		versions = append(versions, v)
+		versions = append(versions, TaggedVersion{Tag: tag, Version: v})
 	}
 
 	if len(versions) == 0 {
+		// The tags may be in the missing history of a shallow clone
+		if shallow, err := d.Git.IsShallow(ctx); err != nil {
+			return nil, err
+		} else if shallow {
+			return nil, fmt.Errorf("%w: %w, so the version tags may be in its missing history", errNoVersionTags, git.ErrShallowRepository)
+		}
 		return nil, errNoVersionTags
 	}
 	return versions, nil
 }
+
+// latest returns the tagged version with the newest version
+func (d *DefaultBumper) latest(tagged []TaggedVersion) TaggedVersion {
+	return slices.MaxFunc(tagged, func(a, b TaggedVersion) int {
+		return d.scheme().Compare(a.Version, b.Version)
+	})
+}
+
+// versionsOf returns the versions of tagged versions
+func versionsOf(tagged []TaggedVersion) version.List {
+	versions := make(version.List, 0, len(tagged))
+	for _, t := range tagged {
+		versions = append(versions, t.Version)
+	}
+	return versions
+}
+
+// nextPreRelease returns the next numbered prerelease, e.g. 1.3.0-rc.2 after
+// 1.3.0-rc.1. Its version is the one of the latest prerelease, or the latest
+// release bumped by base (patch if unset) when base is set or the latest
+// version is a release.
+func (d *DefaultBumper) nextPreRelease(latest version.Version, versions version.List, preID string, base Field) (v version.Version, err error) {
+	if _, perr := version.FromString("0.0.0-" + preID); perr != nil || strings.Contains(preID, ".") || isNumeric(preID) {
+		return v, fmt.Errorf("invalid prerelease identifier %q", preID)
+	}
+
+	scheme := d.scheme()
+	if base == "" && latest.PreRelease != "" {
+		v = release(latest)
+	} else {
+		var released version.Version
+		for _, ver := range versions {
+			if ver.PreRelease == "" && scheme.Compare(ver, released) > 0 {
+				released = ver
+			}
+		}
+		if base == "" {
+			base = FieldPatch
+		}
+		if v, err = scheme.Bump(released, base.String()); err != nil {
+			return v, err
+		}
+	}
+
+	n := 0
+	for _, ver := range versions {
+		id, num, ok := strings.Cut(ver.PreRelease, ".")
+		if !ok || id != preID || release(ver) != v {
+			continue
+		}
+		if i, aerr := strconv.Atoi(num); aerr == nil && i > n {
+			n = i
+		}
+	}
+	v.PreRelease = fmt.Sprintf("%s.%d", preID, n+1)
+
+	if len(versions) > 0 && scheme.Compare(v, latest) <= 0 {
+		return v, fmt.Errorf("prerelease %s is not newer than %s", scheme.Format(v), scheme.Format(latest))
+	}
+	return v, nil
+}
+
+// promote returns the release of the latest version, which must be a
+// prerelease. With requireHead, ref must be the commit tagged with it.
+func (d *DefaultBumper) promote(ctx context.Context, latest TaggedVersion, ref string, requireHead bool) (version.Version, error) {
+	scheme := d.scheme()
+	v := release(latest.Version)
+	if scheme.Compare(v, latest.Version) <= 0 {
+		return v, fmt.Errorf("latest version %s is not a prerelease", scheme.Format(latest.Version))
+	}
+	if !requireHead {
+		return v, nil
+	}
+
+	tag := latest.Tag
+	tagged, err := d.Git.ResolveRef(ctx, tag)
+	if errors.Is(err, git.ErrUnknownRevision) {
+		return v, fmt.Errorf("tag %s not found: %w", tag, err)
+	} else if err != nil {
+		return v, err
+	}
+	head, err := d.Git.LastCommit(ctx, ref, false)
+	if err != nil {
+		return v, fmt.Errorf("getting current commit sha %w", err)
+	}
+	if head != tagged {
+		return v, fmt.Errorf("%s is not the commit tagged %s", ref, tag)
+	}
+	return v, nil
+}
+
+// release returns the version without its prerelease and build metadata
+func release(v version.Version) version.Version {
+	v.PreRelease, v.Build = "", ""
+	return v
+}
+
+// isNumeric reports whether s only contains digits
+func isNumeric(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// VerifyTag checks the signature of a tag
+func (d *DefaultBumper) VerifyTag(ctx context.Context, tag string) error {
+	return d.Git.VerifyTag(ctx, tag)
+}
+
+// Parse returns the version of a tag, or of a version string without the prefix
+func (d *DefaultBumper) Parse(prefix, tag string) (version.Version, error) {
+	return d.scheme().Parse(strings.TrimPrefix(tag, prefix))
+}
+
+// scheme returns the configured Scheme or the default one
+func (d *DefaultBumper) scheme() version.Scheme {
+	if d.Scheme == nil {
+		return version.DefaultScheme
+	}
+	return d.Scheme
+}
@@ -1,10 +1,15 @@
 package bumper
 
 import (
+	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/screwdriver-cd/gitversion/git"
+	"github.com/screwdriver-cd/gitversion/testutil"
+	"github.com/screwdriver-cd/gitversion/version"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -27,10 +32,24 @@ func bumperForTest(ctrl *gomock.Controller, options ...MockGitOption) Bumper {
 	}
 }
 
+func schemeBumperForTest(ctrl *gomock.Controller, scheme version.Scheme, options ...MockGitOption) Bumper {
+	return &DefaultBumper{
+		Git:    mockGitForTest(ctrl, options...),
+		Scheme: scheme,
+	}
+}
+
+func calVerForTest(t *testing.T, layout string, now time.Time) version.Scheme {
+	t.Helper()
+	scheme, err := version.NewCalVer(layout, func() time.Time { return now })
+	require.NoError(t, err)
+	return scheme
+}
+
 func withGitTags(tags ...string) MockGitOption {
 	return func(mockGit *git.MockGit) {
 		mockGit.EXPECT().
-			Tags(gomock.Any()).
+			Tags(gomock.Any(), gomock.Any(), gomock.Any()).
 			Return(tags, nil)
 	}
 }
@@ -49,30 +68,42 @@ func withFakeGitTags() MockGitOption {
 func withEmptyGitTags() MockGitOption {
 	return func(mockGit *git.MockGit) {
 		mockGit.EXPECT().
-			Tags(gomock.Any()).
+			Tags(gomock.Any(), gomock.Any(), gomock.Any()).
 			Return(nil, nil)
+		// Without version tags, shallow clones are checked for missing ones
+		mockGit.EXPECT().
+			IsShallow(gomock.Any()).
+			Return(false, nil)
 	}
 }
 
 func withExpectedTag(tag string) MockGitOption {
 	return func(mockGit *git.MockGit) {
 		mockGit.EXPECT().
-			Tag(gomock.Eq(tag))
+			Tag(gomock.Any(), gomock.Eq(tag), gomock.Eq("HEAD"))
 	}
 }
 
-func withTagged(tagged bool) MockGitOption {
+func withTagsPointingAt(tags ...string) MockGitOption {
 	return func(mockGit *git.MockGit) {
 		mockGit.EXPECT().
-			Tagged().
-			Return(tagged, nil)
+			TagsPointingAt(gomock.Any(), gomock.Eq("HEAD")).
+			Return(tags, nil)
+	}
+}
+
+func withTagsContaining(ref string, tags ...string) MockGitOption {
+	return func(mockGit *git.MockGit) {
+		mockGit.EXPECT().
+			TagsContaining(gomock.Any(), gomock.Eq(ref)).
+			Return(tags, nil)
 	}
 }
 
 func withLastCommitMessage(message string) MockGitOption {
 	return func(mockGit *git.MockGit) {
 		mockGit.EXPECT().
-			LastCommitMessage().
+			LastCommitMessage(gomock.Any(), gomock.Eq("HEAD")).
 			Return(message, nil)
 	}
 }
@@ -80,7 +111,71 @@ func withLastCommitMessage(message string) MockGitOption {
 func withLastCommit(commit string) MockGitOption {
 	return func(mockGit *git.MockGit) {
 		mockGit.EXPECT().
-			LastCommit(gomock.Any()).
+			LastCommit(gomock.Any(), gomock.Eq("HEAD"), gomock.Any()).
+			Return(commit, nil)
+	}
+}
+
+// tagOptionsMatcher matches the TagOptions passed to Git.Tag, either as a
+// single option or as a slice of options
+func tagOptionsMatcher(expected git.TagOptions) gomock.Matcher {
+	return testutil.NewMatcherFunc(fmt.Sprintf("%+v", expected), func(x interface{}) bool {
+		var options []git.TagOption
+		switch o := x.(type) {
+		case git.TagOption:
+			options = []git.TagOption{o}
+		case []git.TagOption:
+			options = o
+		}
+		return *git.NewTagOptions(options...) == expected
+	})
+}
+
+func withExpectedTagOptions(tag string, expected git.TagOptions) MockGitOption {
+	return func(mockGit *git.MockGit) {
+		mockGit.EXPECT().
+			Tag(gomock.Any(), gomock.Eq(tag), gomock.Eq("HEAD"), tagOptionsMatcher(expected))
+	}
+}
+
+func withExpectedAnnotatedTag(tag, message string) MockGitOption {
+	return withExpectedTagOptions(tag, git.TagOptions{Message: message})
+}
+
+func withCommitSubjects(since string, subjects ...string) MockGitOption {
+	return func(mockGit *git.MockGit) {
+		mockGit.EXPECT().
+			CommitSubjects(gomock.Any(), gomock.Eq(since), gomock.Eq("HEAD")).
+			Return(subjects, nil)
+	}
+}
+
+func withPush(remote, tag string, err error) MockGitOption {
+	return func(mockGit *git.MockGit) {
+		mockGit.EXPECT().
+			Push(gomock.Any(), gomock.Eq(remote), gomock.Eq(tag)).
+			Return(err)
+	}
+}
+
+func withDeletedTag(tag string) MockGitOption {
+	return func(mockGit *git.MockGit) {
+		mockGit.EXPECT().
+			DeleteTag(gomock.Any(), gomock.Eq(tag))
+	}
+}
+
+func withFetchTags(remote string) MockGitOption {
+	return func(mockGit *git.MockGit) {
+		mockGit.EXPECT().
+			FetchTags(gomock.Any(), gomock.Eq(remote))
+	}
+}
+
+func withResolveRef(ref, commit string) MockGitOption {
+	return func(mockGit *git.MockGit) {
+		mockGit.EXPECT().
+			ResolveRef(gomock.Any(), gomock.Eq(ref)).
 			Return(commit, nil)
 	}
 }
@@ -97,7 +192,7 @@ func TestVersions(t *testing.T) {
 	}
 	b := bumperForTest(ctrl, withFakeGitTags())
 
-	v, err := b.Versions("", false)
+	v, err := b.Versions(t.Context(), "", "HEAD", false)
 	require.NoError(t, err)
 
 	assert.Equal(t, len(expected), len(v))
@@ -112,7 +207,7 @@ func TestNoVersions(t *testing.T) {
 
 	b := bumperForTest(ctrl, withEmptyGitTags())
 
-	v, err := b.Versions("", false)
+	v, err := b.Versions(t.Context(), "", "HEAD", false)
 	require.Error(t, err, "error value for an empty version list should be non-nil")
 	assert.Empty(t, v)
 }
@@ -124,22 +219,164 @@ func TestLatestVersion(t *testing.T) {
 
 	want := "2.1.2"
 
-	latest, err := b.LatestVersion("", false)
+	latest, err := b.LatestVersion(t.Context(), "", "HEAD", false)
 	require.NoError(t, err)
 	assert.Equal(t, want, latest.String())
 }
 
+func TestLatestVersionPrefersRelease(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(ctrl, withGitTags("1.3.0-rc.2", "1.3.0-rc.10", "1.3.0", "1.2.9"))
+
+	latest, err := b.LatestVersion(t.Context(), "", "HEAD", false)
+	require.NoError(t, err)
+	assert.Equal(t, "1.3.0", latest.String())
+}
+
+func TestLatestVersionIgnoresBuildMetadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withGitTags("1.2.3+sd.99", "1.2.4+sd.1", "1.2.3+sd.100"),
+	)
+
+	latest, err := b.LatestVersion(t.Context(), "", "HEAD", false)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.4+sd.1", latest.String())
+}
+
+func TestLint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockGit := mockGitForTest(
+		ctrl,
+		withGitTags("v1.2", "v1.3.0", "v1.3.0+build.1", "v1.4.0", "v2.0.0", "latest", "other1.0.0"),
+		withTagsContaining("v1.4.0", "v1.4.0"),
+		withTagsContaining("v2.0.0", "v1.4.0", "v2.0.0"),
+		withResolveRef("v1.4.0", "c3"),
+		withResolveRef("v2.0.0", "c2"),
+	)
+	var b Bumper = &DefaultBumper{Git: mockGit}
+
+	issues, err := b.Lint(t.Context(), "v", "HEAD", false)
+	require.NoError(t, err)
+	require.Len(t, issues, 3)
+
+	assert.Equal(t, "v1.2", issues[0].Tag)
+	assert.Equal(t, ProblemUnparsable, issues[0].Problem)
+	assert.Equal(t, LintIssue{
+		Tag:     "v1.3.0+build.1",
+		Problem: ProblemDuplicate,
+		Message: "same version as v1.3.0",
+		Related: "v1.3.0",
+	}, issues[1])
+	assert.Equal(t, LintIssue{
+		Tag:     "v2.0.0",
+		Problem: ProblemOrder,
+		Message: "tagged on an ancestor of the older version v1.4.0",
+		Related: "v1.4.0",
+	}, issues[2])
+
+	// Versions are compared with all the older ones, not only the previous
+	// one: on a line of commits c1, c2, c3, v3.0.0 is on an ancestor of v1.0.0
+	mockGit = mockGitForTest(
+		ctrl,
+		withGitTags("v2.0.0", "v3.0.0", "v1.0.0"),
+		withTagsContaining("v2.0.0", "v1.0.0", "v2.0.0", "v3.0.0"),
+		withTagsContaining("v3.0.0", "v1.0.0", "v3.0.0"),
+		withResolveRef("v2.0.0", "c1"),
+		withResolveRef("v3.0.0", "c2"),
+	)
+	mockGit.EXPECT().ResolveRef(gomock.Any(), "v1.0.0").Return("c3", nil).Times(2)
+	b = &DefaultBumper{Git: mockGit}
+
+	issues, err = b.Lint(t.Context(), "v", "HEAD", false)
+	require.NoError(t, err)
+	assert.Equal(t, []LintIssue{
+		{
+			Tag:     "v2.0.0",
+			Problem: ProblemOrder,
+			Message: "tagged on an ancestor of the older version v1.0.0",
+			Related: "v1.0.0",
+		},
+		{
+			Tag:     "v3.0.0",
+			Problem: ProblemOrder,
+			Message: "tagged on an ancestor of the older version v1.0.0",
+			Related: "v1.0.0",
+		},
+	}, issues)
+
+	// Older versions of the same commit are not out of order
+	mockGit = mockGitForTest(
+		ctrl,
+		withGitTags("v1.0.0", "v2.0.0"),
+		withTagsContaining("v2.0.0", "v1.0.0", "v2.0.0"),
+		withResolveRef("v1.0.0", "c1"),
+		withResolveRef("v2.0.0", "c1"),
+	)
+	b = &DefaultBumper{Git: mockGit}
+
+	issues, err = b.Lint(t.Context(), "v", "HEAD", false)
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
+
 func TestBumpAutoTagged(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
+	// The released version is the result, without a new tag
 	b := bumperForTest(
 		ctrl,
-		withExpectedTag("1.1.2"),
-		withGitTags("1.1.1", "0.1.1"),
-		withTagged(true),
+		withGitTags("v1.1.1", "v1.1.0", "v0.1.1"),
+		withTagsPointingAt("latest", "v1.1.0", "v1.1.1", "1.2.0"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithPrefix("v"), WithField(FieldAuto)))
+}
+
+func TestBumpAutoTaggedOtherPrefix(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	// Tags that aren't versions with the prefix don't release the commit
+	b := bumperForTest(
+		ctrl,
+		withExpectedTag("v1.1.2"),
+		withGitTags("v1.1.1", "v0.1.1"),
+		withTagsPointingAt("latest", "1.2.0", "vnext"),
+		withLastCommitMessage("foo bar"),
 	)
 
-	require.NoError(t, b.Bump(WithField(FieldAuto)))
+	require.NoError(t, b.Bump(t.Context(), WithPrefix("v"), WithField(FieldAuto)))
+}
+
+func TestBumpAutoTaggedPreRelease(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	// Prerelease tags don't release the commit
+	b := bumperForTest(
+		ctrl,
+		withExpectedTag("v1.3.1"),
+		withGitTags("v1.2.0", "v1.3.0-rc.1"),
+		withTagsPointingAt("v1.3.0-rc.1", "v1.3.0-g9d8ceaa"),
+		withLastCommitMessage("foo bar"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithPrefix("v"), WithField(FieldAuto)))
+}
+
+func TestBumpAutoTaggedError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockGit := mockGitForTest(ctrl, withGitTags("1.1.1"))
+	mockGit.EXPECT().
+		TagsPointingAt(gomock.Any(), gomock.Eq("HEAD")).
+		Return(nil, fmt.Errorf("fetching tags of HEAD: exit status 129"))
+	var b Bumper = &DefaultBumper{Git: mockGit}
+
+	assert.EqualError(t, b.Bump(t.Context(), WithField(FieldAuto)), "checking if HEAD is released: fetching tags of HEAD: exit status 129")
 }
 
 func TestBumpAutoMatch(t *testing.T) {
@@ -149,11 +386,11 @@ func TestBumpAutoMatch(t *testing.T) {
 		ctrl,
 		withExpectedTag("2.0.0"),
 		withGitTags("1.1.1", "0.1.1"),
-		withTagged(false),
+		withTagsPointingAt(),
 		withLastCommitMessage("[Major] foo"),
 	)
 
-	require.NoError(t, b.Bump(WithField(FieldAuto)))
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldAuto)))
 }
 
 func TestBumpAutoMatchAlternate(t *testing.T) {
@@ -163,11 +400,11 @@ func TestBumpAutoMatchAlternate(t *testing.T) {
 		ctrl,
 		withExpectedTag("2.0.0"),
 		withGitTags("1.1.1", "0.1.1"),
-		withTagged(false),
+		withTagsPointingAt(),
 		withLastCommitMessage("[major bump] foo"),
 	)
 
-	require.NoError(t, b.Bump(WithField(FieldAuto)))
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldAuto)))
 }
 
 func TestBumpAutoMatchFallback(t *testing.T) {
@@ -177,11 +414,11 @@ func TestBumpAutoMatchFallback(t *testing.T) {
 		ctrl,
 		withExpectedTag("1.1.2"),
 		withGitTags("1.1.1", "0.1.1"),
-		withTagged(false),
+		withTagsPointingAt(),
 		withLastCommitMessage("foo bar"),
 	)
 
-	require.NoError(t, b.Bump(WithField(FieldAuto)))
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldAuto)))
 }
 
 func TestBumpPreRelease(t *testing.T) {
@@ -189,12 +426,508 @@ func TestBumpPreRelease(t *testing.T) {
 
 	b := bumperForTest(
 		ctrl,
-		withExpectedTag("1.1.1-9d8ceaa"),
+		withExpectedTag("1.1.1-g9d8ceaa"),
 		withLastCommit("9d8ceaa"),
 		withGitTags("1.1.1", "0.1.1"),
 	)
 
-	require.NoError(t, b.Bump(WithField(FieldPrerelease)))
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPrerelease)))
+}
+
+func TestBumpPreReleaseNumericSHA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withExpectedTag("1.1.1-g0412345"),
+		withLastCommit("0412345"),
+		withGitTags("1.1.1"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPrerelease)))
+}
+
+func TestBumpPreReleaseWithPreID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withExpectedTag("1.3.0-rc.3"),
+		withGitTags("1.2.5", "1.3.0-beta.4", "1.3.0-rc.1", "1.3.0-rc.2", "1.2.0-rc.7"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPrerelease), WithPreID("rc")))
+}
+
+func TestBumpPreReleaseWithPreIDFromRelease(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withExpectedTag("1.2.6-rc.1"),
+		withGitTags("1.2.5", "1.2.5-rc.1"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPrerelease), WithPreID("rc")))
+}
+
+func TestBumpPreReleaseWithBase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withExpectedTag("1.3.0-rc.2"),
+		withGitTags("1.2.5", "1.3.0-rc.1"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPrerelease), WithPreID("rc"), WithBase(FieldMinor)))
+
+	b = bumperForTest(
+		ctrl,
+		withExpectedTag("2.0.0-rc.1"),
+		withGitTags("1.2.5", "1.3.0-rc.1"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPrerelease), WithPreID("rc"), WithBase(FieldMajor)))
+}
+
+func TestBumpPreReleaseWithPreIDNotNewer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withGitTags("1.2.5", "1.3.0-rc.1"),
+	)
+
+	assert.EqualError(t, b.Bump(t.Context(), WithField(FieldPrerelease), WithPreID("beta")), "prerelease 1.3.0-beta.1 is not newer than 1.3.0-rc.1")
+}
+
+func TestBumpPreReleaseWithBadPreID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withGitTags("1.2.5"),
+	)
+
+	assert.EqualError(t, b.Bump(t.Context(), WithField(FieldPrerelease), WithPreID("rc.1")), `invalid prerelease identifier "rc.1"`)
+}
+
+func TestBumpRelease(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withExpectedTag("v1.3.0"),
+		withGitTags("v1.2.5", "v1.3.0-rc.3", "v1.3.0-rc.2"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithPrefix("v"), WithField(FieldRelease)))
+}
+
+func TestBumpReleaseNotPreRelease(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withGitTags("1.2.5", "1.3.0-rc.3", "1.3.0"),
+	)
+
+	assert.EqualError(t, b.Bump(t.Context(), WithField(FieldRelease)), "latest version 1.3.0 is not a prerelease")
+}
+
+func TestBumpReleaseRequireHead(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withExpectedTag("1.3.0"),
+		withGitTags("1.3.0-rc.3"),
+		withResolveRef("1.3.0-rc.3", "9d8ceaa"),
+		withLastCommit("9d8ceaa"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldRelease), WithRequireHead(true)))
+
+	b = bumperForTest(
+		ctrl,
+		withGitTags("1.3.0-rc.3"),
+		withResolveRef("1.3.0-rc.3", "9d8ceaa"),
+		withLastCommit("1644da2"),
+	)
+
+	assert.EqualError(t, b.Bump(t.Context(), WithField(FieldRelease), WithRequireHead(true)), "HEAD is not the commit tagged 1.3.0-rc.3")
+}
+
+func TestBumpReleaseRequireHeadNonCanonicalTag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	// The commit of the tag is resolved by its name
+	b := schemeBumperForTest(
+		ctrl,
+		version.Maven{},
+		withExpectedTag("2.0.0"),
+		withGitTags("2.0-RC1", "1.0"),
+		withResolveRef("2.0-RC1", "9d8ceaa"),
+		withLastCommit("9d8ceaa"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldRelease), WithRequireHead(true)))
+
+	mockGit := mockGitForTest(ctrl, withGitTags("2.0.0-rc.1"))
+	mockGit.EXPECT().
+		ResolveRef(gomock.Any(), gomock.Eq("2.0.0-rc.1")).
+		Return("", fmt.Errorf("resolving 2.0.0-rc.1: %w", git.ErrUnknownRevision))
+	b = &DefaultBumper{Git: mockGit}
+
+	err := b.Bump(t.Context(), WithField(FieldRelease), WithRequireHead(true))
+	assert.ErrorIs(t, err, git.ErrUnknownRevision)
+	assert.EqualError(t, err, "tag 2.0.0-rc.1 not found: resolving 2.0.0-rc.1: unknown revision")
+}
+
+func TestBumpRef(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockGit := mockGitForTest(ctrl)
+	mockGit.EXPECT().Tags(gomock.Any(), gomock.Eq("release"), gomock.Eq(true)).Return([]string{"v1.3.2"}, nil)
+	mockGit.EXPECT().TagsPointingAt(gomock.Any(), gomock.Eq("release")).Return(nil, nil)
+	mockGit.EXPECT().LastCommitMessage(gomock.Any(), gomock.Eq("release")).Return("[minor] add feature", nil)
+	mockGit.EXPECT().LastCommit(gomock.Any(), gomock.Eq("release"), gomock.Eq(false)).Return("9d8ceaaa28f0563e52e1edf3eaae72c814aa1102", nil)
+	mockGit.EXPECT().LastCommit(gomock.Any(), gomock.Eq("release"), gomock.Eq(true)).Return("9d8ceaa", nil)
+	mockGit.EXPECT().CommitSubjects(gomock.Any(), gomock.Eq("v1.3.2"), gomock.Eq("release")).Return([]string{"add feature"}, nil)
+	mockGit.EXPECT().Tag(gomock.Any(), gomock.Eq("v1.4.0+g9d8ceaa"), gomock.Eq("release"), tagOptionsMatcher(git.TagOptions{Message: "v1.4.0+g9d8ceaa\n\n- add feature"}))
+	var b Bumper = &DefaultBumper{Git: mockGit}
+
+	require.NoError(t, b.Bump(
+		t.Context(),
+		WithPrefix("v"),
+		WithRef("release"),
+		WithMerged(true),
+		WithBuildMetadata("g{{.ShortCommit}}"),
+		WithMessage("{{.Tag}}\n\n{{range .Commits}}- {{.}}{{end}}"),
+	))
+
+	mockGit = mockGitForTest(ctrl, withGitTags("1.3.0-rc.3"), withResolveRef("1.3.0-rc.3", "9d8ceaa"))
+	mockGit.EXPECT().LastCommit(gomock.Any(), gomock.Eq("release"), gomock.Eq(false)).Return("9d8ceaaa28f0563e52e1edf3eaae72c814aa1102", nil)
+	b = &DefaultBumper{Git: mockGit}
+
+	assert.EqualError(t, b.Bump(t.Context(), WithField(FieldRelease), WithRef("release"), WithRequireHead(true)), "release is not the commit tagged 1.3.0-rc.3")
+}
+
+func TestBumpWithBuildMetadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withExpectedTag("1.2.4+g9d8ceaa"),
+		withGitTags("1.2.3+g1644da2"),
+		withLastCommit("9d8ceaa"),
+		withLastCommit("9d8ceaa"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPatch), WithBuildMetadata("g{{.ShortCommit}}")))
+}
+
+func TestBumpPreReleaseDropsBuildMetadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withExpectedTag("1.2.3-g9d8ceaa"),
+		withGitTags("1.2.3+g1644da2"),
+		withLastCommit("9d8ceaa"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPrerelease)))
+}
+
+func TestBumpAnnotated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withExpectedAnnotatedTag("v1.4.0", "v1.4.0: minor bump from v1.3.2\n\n- add feature\n- fix typo"),
+		withGitTags("v1.3.2"),
+		withCommitSubjects("v1.3.2", "add feature", "fix typo"),
+	)
+
+	require.NoError(t, b.Bump(
+		t.Context(),
+		WithPrefix("v"),
+		WithField(FieldMinor),
+		WithMessage("{{.Tag}}: {{.Field}} bump from {{.Previous}}\n\n{{range .Commits}}- {{.}}\n{{end}}"),
+	))
+}
+
+func TestBumpAnnotatedNonCanonicalTag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	// Previous is the name of the tag, not the format of its version
+	b := schemeBumperForTest(
+		ctrl,
+		version.Maven{},
+		withExpectedAnnotatedTag("1.1.0", "1.1.0 from 1.0\n\n- add feature"),
+		withGitTags("1.0"),
+		withCommitSubjects("1.0", "add feature"),
+	)
+
+	require.NoError(t, b.Bump(
+		t.Context(),
+		WithField(FieldMinor),
+		WithMessage("{{.Tag}} from {{.Previous}}\n\n{{range .Commits}}- {{.}}\n{{end}}"),
+	))
+}
+
+func TestTaggedVersions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := schemeBumperForTest(ctrl, version.Maven{}, withGitTags("v1.0", "v1.1.0", "latest", "1.2"))
+
+	tagged, err := b.TaggedVersions(t.Context(), "v", "HEAD", false)
+	require.NoError(t, err)
+	assert.Equal(t, []TaggedVersion{
+		{Tag: "v1.0", Version: version.Version{Major: 1}},
+		{Tag: "v1.1.0", Version: version.Version{Major: 1, Minor: 1}},
+	}, tagged)
+}
+
+func TestBumpBuildMetadataUnsupported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := schemeBumperForTest(ctrl, version.Maven{})
+
+	err := b.Bump(t.Context(), WithField(FieldMinor), WithBuildMetadata("sd.1"))
+	assert.ErrorIs(t, err, version.ErrBuildMetadata)
+}
+
+func TestBumpBaseWithoutPreID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(ctrl)
+
+	err := b.Bump(t.Context(), WithField(FieldPrerelease), WithBase(FieldMinor))
+	assert.ErrorIs(t, err, errBaseWithoutPreID)
+}
+
+func TestBumpAnnotatedDefaultMessage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withExpectedAnnotatedTag("0.0.1", "0.0.1"),
+		withEmptyGitTags(),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPatch), WithAnnotate(true)))
+}
+
+func TestBumpSigned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withExpectedTagOptions("v1.3.3", git.TagOptions{Message: "v1.3.3", Sign: true, SigningKey: "~/.ssh/id.pub", SignFormat: "ssh"}),
+		withGitTags("v1.3.2"),
+	)
+
+	require.NoError(t, b.Bump(
+		t.Context(),
+		WithPrefix("v"),
+		WithField(FieldPatch),
+		WithSigningKey("~/.ssh/id.pub"),
+		WithSignFormat("ssh"),
+	))
+
+	b = bumperForTest(
+		ctrl,
+		withExpectedTagOptions("1.3.3", git.TagOptions{Message: "1.3.3", Sign: true}),
+		withGitTags("1.3.2"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPatch), WithSign(true)))
+}
+
+func TestBumpPush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withExpectedTag("v1.3.3"),
+		withGitTags("v1.3.2"),
+		withPush("origin", "v1.3.3", nil),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithPrefix("v"), WithField(FieldPatch), WithPush(true)))
+
+	b = bumperForTest(
+		ctrl,
+		withExpectedTag("1.4.0"),
+		withGitTags("1.3.2"),
+		withPush("upstream", "1.4.0", nil),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldMinor), WithPush(true), WithRemote("upstream")))
+}
+
+func TestBumpPushRejected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	rejected := fmt.Errorf("pushing tag 1.3.3 to origin: %w", git.ErrPushRejected)
+
+	b := bumperForTest(
+		ctrl,
+		withExpectedTag("1.3.3"),
+		withGitTags("1.3.2"),
+		withPush("origin", "1.3.3", rejected),
+		withDeletedTag("1.3.3"),
+	)
+
+	err := b.Bump(t.Context(), WithField(FieldPatch), WithPush(true))
+	assert.ErrorIs(t, err, git.ErrPushRejected)
+}
+
+func TestBumpGitErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	exists := &git.Error{
+		Args:   []string{"tag", "1.3.3", "HEAD^{commit}"},
+		Stderr: "fatal: tag '1.3.3' already exists\n",
+	}
+
+	mockGit := mockGitForTest(ctrl, withGitTags("1.3.2"))
+	mockGit.EXPECT().
+		Tag(gomock.Any(), gomock.Eq("1.3.3"), gomock.Eq("HEAD")).
+		Return(fmt.Errorf("tagging the commit in git: %w", exists))
+	var b Bumper = &DefaultBumper{Git: mockGit}
+
+	err := b.Bump(t.Context(), WithField(FieldPatch))
+	assert.ErrorIs(t, err, git.ErrTagExists)
+	assert.EqualError(t, err, "creating new tag 1.3.3: tagging the commit in git: tag '1.3.3' already exists")
+}
+
+func TestBumpPushCancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	mockGit := mockGitForTest(ctrl, withGitTags("1.3.2"), withExpectedTag("1.3.3"))
+	mockGit.EXPECT().
+		Push(gomock.Any(), gomock.Eq("origin"), gomock.Eq("1.3.3")).
+		DoAndReturn(func(context.Context, string, string) error {
+			cancel()
+			return context.Canceled
+		})
+	// The local tag is deleted even though the context is cancelled
+	mockGit.EXPECT().
+		DeleteTag(testutil.NewMatcherFunc("a context that is not done", func(x interface{}) bool {
+			return x.(context.Context).Err() == nil
+		}), gomock.Eq("1.3.3"))
+	var b Bumper = &DefaultBumper{Git: mockGit}
+
+	assert.ErrorIs(t, b.Bump(ctx, WithField(FieldPatch), WithPush(true)), context.Canceled)
+}
+
+func TestBumpPushDryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(ctrl, withGitTags("1.3.2"))
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPatch), WithPush(true), WithDryRun(true)))
+}
+
+func TestBumpFetch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockGit := mockGitForTest(ctrl)
+	gomock.InOrder(
+		mockGit.EXPECT().FetchTags(gomock.Any(), gomock.Eq("upstream")),
+		mockGit.EXPECT().Tags(gomock.Any(), gomock.Any(), gomock.Any()).Return([]string{"1.3.2"}, nil),
+		mockGit.EXPECT().Tag(gomock.Any(), gomock.Eq("1.3.3"), gomock.Eq("HEAD")),
+	)
+	var b Bumper = &DefaultBumper{Git: mockGit}
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPatch), WithFetch(true), WithRemote("upstream")))
+
+	b = bumperForTest(
+		ctrl,
+		withFetchTags("origin"),
+		withEmptyGitTags(),
+	)
+
+	assert.ErrorIs(t, b.Bump(t.Context(), WithField(FieldPatch), WithFetch(true), WithRequireTags(true)), errNoVersionTags)
+}
+
+func TestBumpRequireTags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(ctrl, withEmptyGitTags())
+
+	assert.ErrorIs(t, b.Bump(t.Context(), WithField(FieldPatch), WithRequireTags(true)), errNoVersionTags)
+
+	b = bumperForTest(
+		ctrl,
+		withExpectedTag("1.3.3"),
+		withGitTags("1.3.2"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPatch), WithRequireTags(true)))
+}
+
+func TestBumpShallow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	// The version of a shallow clone without version tags would be wrong
+	mockGit := mockGitForTest(ctrl)
+	mockGit.EXPECT().Tags(gomock.Any(), gomock.Eq("HEAD"), gomock.Eq(true)).Return(nil, nil)
+	mockGit.EXPECT().IsShallow(gomock.Any()).Return(true, nil)
+	var b Bumper = &DefaultBumper{Git: mockGit}
+
+	err := b.Bump(t.Context(), WithField(FieldPatch), WithMerged(true))
+	assert.ErrorIs(t, err, git.ErrShallowRepository)
+	assert.EqualError(t, err, "getting latest version 0.0.0: no valid version tags found: the repository is a shallow clone, so the version tags may be in its missing history")
+}
+
+func TestBumpDeepen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	// The clone is deepened until a version tag is found
+	mockGit := mockGitForTest(ctrl, withExpectedTag("1.3.3"))
+	gomock.InOrder(
+		mockGit.EXPECT().Tags(gomock.Any(), gomock.Eq("HEAD"), gomock.Eq(true)).Return([]string{"latest"}, nil),
+		mockGit.EXPECT().IsShallow(gomock.Any()).Return(true, nil),
+		mockGit.EXPECT().Deepen(gomock.Any(), gomock.Eq("upstream"), gomock.Eq(50)),
+		mockGit.EXPECT().Tags(gomock.Any(), gomock.Eq("HEAD"), gomock.Eq(true)).Return([]string{"latest"}, nil),
+		mockGit.EXPECT().IsShallow(gomock.Any()).Return(true, nil),
+		mockGit.EXPECT().Deepen(gomock.Any(), gomock.Eq("upstream"), gomock.Eq(100)),
+		mockGit.EXPECT().Tags(gomock.Any(), gomock.Eq("HEAD"), gomock.Eq(true)).Return([]string{"latest", "1.3.2"}, nil),
+	)
+	var b Bumper = &DefaultBumper{Git: mockGit}
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPatch), WithMerged(true), WithRemote("upstream"), WithDeepen(DeepenAuto)))
+}
+
+func TestBumpDeepenUnshallow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	// The whole history is fetched last, and has no version tags
+	mockGit := mockGitForTest(ctrl, withExpectedTag("0.0.1"))
+	mockGit.EXPECT().Tags(gomock.Any(), gomock.Eq("HEAD"), gomock.Eq(true)).Return(nil, nil).Times(7)
+	mockGit.EXPECT().IsShallow(gomock.Any()).Return(true, nil).Times(6)
+	for _, depth := range []int{50, 100, 200, 400, 800} {
+		mockGit.EXPECT().Deepen(gomock.Any(), gomock.Eq("origin"), gomock.Eq(depth))
+	}
+	mockGit.EXPECT().Deepen(gomock.Any(), gomock.Eq("origin"), gomock.Eq(0))
+	mockGit.EXPECT().IsShallow(gomock.Any()).Return(false, nil)
+	var b Bumper = &DefaultBumper{Git: mockGit}
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPatch), WithMerged(true), WithDeepen(DeepenAuto)))
+
+	// Failing to deepen fails the bump
+	mockGit = mockGitForTest(ctrl)
+	mockGit.EXPECT().Tags(gomock.Any(), gomock.Eq("HEAD"), gomock.Eq(true)).Return(nil, nil)
+	mockGit.EXPECT().IsShallow(gomock.Any()).Return(true, nil)
+	mockGit.EXPECT().Deepen(gomock.Any(), gomock.Eq("origin"), gomock.Eq(50)).Return(fmt.Errorf("deepening the clone from origin: %w", git.ErrUnsupported))
+	b = &DefaultBumper{Git: mockGit}
+
+	assert.ErrorIs(t, b.Bump(t.Context(), WithField(FieldPatch), WithMerged(true), WithDeepen(DeepenAuto)), git.ErrUnsupported)
 }
 
 func TestBumpPatch(t *testing.T) {
@@ -206,7 +939,7 @@ func TestBumpPatch(t *testing.T) {
 		withGitTags("1.1.1", "0.1.1"),
 	)
 
-	require.NoError(t, b.Bump(WithField(FieldPatch)))
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPatch)))
 }
 
 func TestBumpMinor(t *testing.T) {
@@ -218,7 +951,19 @@ func TestBumpMinor(t *testing.T) {
 		withGitTags("1.1.1", "0.1.1"),
 	)
 
-	require.NoError(t, b.Bump(WithField(FieldMinor)))
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldMinor)))
+}
+
+func TestBumpPatchFromPreRelease(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withExpectedTag("1.3.1"),
+		withGitTags("1.2.0", "1.3.0-rc.1"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPatch)))
 }
 
 func TestBumpMinorDryRun(t *testing.T) {
@@ -229,7 +974,7 @@ func TestBumpMinorDryRun(t *testing.T) {
 		withGitTags("1.1.1", "0.1.1"),
 	)
 
-	require.NoError(t, b.Bump(WithField(FieldMinor), WithDryRun(true)))
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldMinor), WithDryRun(true)))
 }
 
 func TestBumpMajor(t *testing.T) {
@@ -241,7 +986,7 @@ func TestBumpMajor(t *testing.T) {
 		withGitTags("1.1.1", "0.1.1"),
 	)
 
-	require.NoError(t, b.Bump(WithField(FieldMajor)))
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldMajor)))
 }
 
 func TestBumpWithNoVersions(t *testing.T) {
@@ -253,7 +998,7 @@ func TestBumpWithNoVersions(t *testing.T) {
 		withEmptyGitTags(),
 	)
 
-	require.NoError(t, b.Bump(WithField(FieldPatch)))
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldPatch)))
 }
 
 func TestBumpWithBadField(t *testing.T) {
@@ -264,7 +1009,7 @@ func TestBumpWithBadField(t *testing.T) {
 		withEmptyGitTags(),
 	)
 
-	assert.EqualError(t, b.Bump(WithField("foobar")), "unknown field type")
+	assert.EqualError(t, b.Bump(t.Context(), WithField("foobar")), "unknown field type")
 }
 
 func TestPrefix(t *testing.T) {
@@ -279,11 +1024,121 @@ func TestPrefix(t *testing.T) {
 		),
 	)
 
-	latest, err := b.LatestVersion("bigPrefix", false)
+	latest, err := b.LatestVersion(t.Context(), "bigPrefix", "HEAD", false)
 	require.NoError(t, err)
 	assert.Equal(t, "2.1.0", latest.String())
 }
 
+func TestParse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := schemeBumperForTest(ctrl, version.PEP440{})
+
+	v, err := b.Parse("v", "v1.2.3rc1")
+	require.NoError(t, err)
+	assert.Equal(t, version.Version{Major: 1, Minor: 2, Patch: 3, PreRelease: "rc.1"}, v)
+
+	v, err = b.Parse("v", "1.2.4")
+	require.NoError(t, err)
+	assert.Equal(t, version.Version{Major: 1, Minor: 2, Patch: 4}, v)
+
+	_, err = b.Parse("v", "latest")
+	assert.Error(t, err)
+}
+
+func TestBumpCalVer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	now := time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC)
+
+	b := schemeBumperForTest(
+		ctrl,
+		calVerForTest(t, "YYYY.0M.MICRO", now),
+		withExpectedTag("v2024.03.2"),
+		withGitTags("v2024.02.7", "v2024.03.1", "v1.2.3", "2024.03.5"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithPrefix("v"), WithField(FieldPatch)))
+}
+
+func TestBumpCalVerNewPeriod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	now := time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	b := schemeBumperForTest(
+		ctrl,
+		calVerForTest(t, "YYYY.0M.MICRO", now),
+		withExpectedTag("2024.04.0"),
+		withGitTags("2024.02.7", "2024.03.1"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldMinor)))
+}
+
+func TestBumpRevision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := schemeBumperForTest(
+		ctrl,
+		version.SemVer{Components: 4},
+		withExpectedTag("v1.4.2.8"),
+		withGitTags("v1.4.2.7", "v1.4.2", "v1.3.9.12"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithPrefix("v"), WithField(FieldRevision)))
+}
+
+func TestBumpAutoMatchRevision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := schemeBumperForTest(
+		ctrl,
+		version.SemVer{Components: 4},
+		withExpectedTag("1.4.2.8"),
+		withGitTags("1.4.2.7"),
+		withTagsPointingAt(),
+		withLastCommitMessage("[revision] fix the installer"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldAuto)))
+}
+
+func TestBumpTwoComponents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := schemeBumperForTest(
+		ctrl,
+		version.SemVer{Components: 2},
+		withExpectedTag("1.5"),
+		withGitTags("1.4", "1.3", "1.4.2"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldMinor)))
+}
+
+func TestBumpMavenSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := schemeBumperForTest(
+		ctrl,
+		version.Maven{},
+		withExpectedTag("1.2.4-SNAPSHOT"),
+		withGitTags("1.2.3-RC2", "1.2.3", "1.2.3-SNAPSHOT", "1.2.2-Final"),
+	)
+
+	require.NoError(t, b.Bump(t.Context(), WithField(FieldSnapshot)))
+}
+
+func TestBumpSnapshotWithSemVer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	b := bumperForTest(
+		ctrl,
+		withGitTags("1.2.3"),
+	)
+
+	assert.EqualError(t, b.Bump(t.Context(), WithField(FieldSnapshot)), "unknown field type")
+}
+
 func ExampleBumper_Bump() {
 	t := &testing.T{}
 	ctrl := gomock.NewController(t)
@@ -295,6 +1150,6 @@ func ExampleBumper_Bump() {
 		withGitTags("v2.2.0"),
 	)
 
-	require.NoError(t, b.Bump(WithPrefix("v"), WithField(FieldPatch)))
+	require.NoError(t, b.Bump(t.Context(), WithPrefix("v"), WithField(FieldPatch)))
 	// Output: v2.2.1
 }
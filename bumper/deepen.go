@@ -0,0 +1,48 @@
+package bumper
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/screwdriver-cd/gitversion/git"
+)
+
+//go:generate go run github.com/abice/go-enum -f $GOFILE --marshal --names
+
+// Deepen ENUM(never, auto)
+type Deepen string
+
+const (
+	// deepenDepth is the number of commits fetched by the first deepening of
+	// a shallow clone; it doubles every time up to maxDeepenDepth, after
+	// which the whole history is fetched
+	deepenDepth    = 50
+	maxDeepenDepth = 800
+)
+
+// deepenedVersions returns the versions of the bump, fetching more history
+// of a shallow clone from the remote until a version tag is found when
+// deepening is enabled
+func (d *DefaultBumper) deepenedVersions(ctx context.Context, opts *bumpOptions) ([]TaggedVersion, error) {
+	versions, err := d.TaggedVersions(ctx, opts.prefix, opts.ref, opts.merged)
+	if opts.deepen != DeepenAuto {
+		return versions, err
+	}
+	for depth := deepenDepth; errors.Is(err, git.ErrShallowRepository); depth *= 2 {
+		if depth > maxDeepenDepth {
+			depth = 0
+			log.Printf("Fetching the whole history of the shallow clone from %s", opts.remote)
+		} else {
+			log.Printf("Deepening the shallow clone by %d commits from %s", depth, opts.remote)
+		}
+		if err = d.Git.Deepen(ctx, opts.remote, depth); err != nil {
+			return nil, err
+		}
+		versions, err = d.TaggedVersions(ctx, opts.prefix, opts.ref, opts.merged)
+		if depth == 0 {
+			break
+		}
+	}
+	return versions, err
+}
@@ -0,0 +1,73 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version:
+// Revision:
+// Build Date:
+// Built By:
+
+package bumper
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DeepenNever is a Deepen of type never.
+	DeepenNever Deepen = "never"
+	// DeepenAuto is a Deepen of type auto.
+	DeepenAuto Deepen = "auto"
+)
+
+var ErrInvalidDeepen = fmt.Errorf("not a valid Deepen, try [%s]", strings.Join(_DeepenNames, ", "))
+
+var _DeepenNames = []string{
+	string(DeepenNever),
+	string(DeepenAuto),
+}
+
+// DeepenNames returns a list of possible string values of Deepen.
+func DeepenNames() []string {
+	tmp := make([]string, len(_DeepenNames))
+	copy(tmp, _DeepenNames)
+	return tmp
+}
+
+// String implements the Stringer interface.
+func (x Deepen) String() string {
+	return string(x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x Deepen) IsValid() bool {
+	_, err := ParseDeepen(string(x))
+	return err == nil
+}
+
+var _DeepenValue = map[string]Deepen{
+	"never": DeepenNever,
+	"auto":  DeepenAuto,
+}
+
+// ParseDeepen attempts to convert a string to a Deepen.
+func ParseDeepen(name string) (Deepen, error) {
+	if x, ok := _DeepenValue[name]; ok {
+		return x, nil
+	}
+	return Deepen(""), fmt.Errorf("%s is %w", name, ErrInvalidDeepen)
+}
+
+// MarshalText implements the text marshaller method.
+func (x Deepen) MarshalText() ([]byte, error) {
+	return []byte(string(x)), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *Deepen) UnmarshalText(text []byte) error {
+	tmp, err := ParseDeepen(string(text))
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
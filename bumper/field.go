@@ -2,5 +2,5 @@ package bumper
 
 //go:generate go run github.com/abice/go-enum -f $GOFILE --marshal --names
 
-// Field ENUM(auto, major, minor, patch, prerelease)
+// Field ENUM(auto, major, minor, patch, revision, prerelease, snapshot, release)
 type Field string
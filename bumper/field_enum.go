@@ -20,8 +20,14 @@ const (
 	FieldMinor Field = "minor"
 	// FieldPatch is a Field of type patch.
 	FieldPatch Field = "patch"
+	// FieldRevision is a Field of type revision.
+	FieldRevision Field = "revision"
 	// FieldPrerelease is a Field of type prerelease.
 	FieldPrerelease Field = "prerelease"
+	// FieldSnapshot is a Field of type snapshot.
+	FieldSnapshot Field = "snapshot"
+	// FieldRelease is a Field of type release.
+	FieldRelease Field = "release"
 )
 
 var ErrInvalidField = fmt.Errorf("not a valid Field, try [%s]", strings.Join(_FieldNames, ", "))
@@ -31,7 +37,10 @@ var _FieldNames = []string{
 	string(FieldMajor),
 	string(FieldMinor),
 	string(FieldPatch),
+	string(FieldRevision),
 	string(FieldPrerelease),
+	string(FieldSnapshot),
+	string(FieldRelease),
 }
 
 // FieldNames returns a list of possible string values of Field.
@@ -58,7 +67,10 @@ var _FieldValue = map[string]Field{
 	"major":      FieldMajor,
 	"minor":      FieldMinor,
 	"patch":      FieldPatch,
+	"revision":   FieldRevision,
 	"prerelease": FieldPrerelease,
+	"snapshot":   FieldSnapshot,
+	"release":    FieldRelease,
 }
 
 // ParseField attempts to convert a string to a Field.
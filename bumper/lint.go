@@ -0,0 +1,130 @@
+package bumper
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+//go:generate go run github.com/abice/go-enum -f $GOFILE --marshal --names
+
+type (
+	// Problem ENUM(unparsable, duplicate, order)
+	Problem string
+
+	// LintIssue is a problem found with a tag
+	LintIssue struct {
+		Tag     string  `json:"tag"`
+		Problem Problem `json:"problem"`
+		Message string  `json:"message"`
+		// Related is the other tag involved in duplicate and order problems
+		Related string `json:"related,omitempty"`
+	}
+)
+
+// Lint checks the tags matching the prefix and reports tags that look like
+// versions but can't be parsed, tags of the same version written
+// differently, and newer versions tagged on an ancestor of an older version
+func (d *DefaultBumper) Lint(ctx context.Context, prefix, ref string, merged bool) ([]LintIssue, error) {
+	scheme := d.scheme()
+	tags, err := d.Git.Tags(ctx, ref, merged)
+	if err != nil {
+		return nil, fmt.Errorf("fetching git tags: %w", err)
+	}
+
+	var issues []LintIssue
+	var versions []TaggedVersion
+	for _, tag := range tags {
+		if len(tag) <= len(prefix) || tag[:len(prefix)] != prefix {
+			continue
+		}
+		v, err := d.Parse(prefix, tag)
+		if err != nil {
+			if looksLikeVersion(tag[len(prefix):]) {
+				issues = append(issues, LintIssue{Tag: tag, Problem: ProblemUnparsable, Message: err.Error()})
+			}
+			continue
+		}
+		versions = append(versions, TaggedVersion{Tag: tag, Version: v})
+	}
+
+	slices.SortStableFunc(versions, func(a, b TaggedVersion) int {
+		return scheme.Compare(a.Version, b.Version)
+	})
+
+	// Duplicates are compared with the first tag of their version only.
+	// Each version is compared with all the older ones, newest first, through
+	// the tags containing its commit, so the cost grows with the number of
+	// versions rather than the number of pairs.
+	var older []TaggedVersion
+	for _, cur := range versions {
+		if n := len(older); n > 0 && scheme.Compare(older[n-1].Version, cur.Version) == 0 {
+			issues = append(issues, LintIssue{
+				Tag:     cur.Tag,
+				Problem: ProblemDuplicate,
+				Message: fmt.Sprintf("same version as %s", older[n-1].Tag),
+				Related: older[n-1].Tag,
+			})
+			continue
+		}
+
+		if len(older) > 0 {
+			related, err := d.olderDescendant(ctx, cur.Tag, older)
+			if err != nil {
+				return nil, err
+			}
+			if related != "" {
+				issues = append(issues, LintIssue{
+					Tag:     cur.Tag,
+					Problem: ProblemOrder,
+					Message: fmt.Sprintf("tagged on an ancestor of the older version %s", related),
+					Related: related,
+				})
+			}
+		}
+		older = append(older, cur)
+	}
+	return issues, nil
+}
+
+// olderDescendant returns the newest of the older tags on a descendant of the
+// commit of tag, ignoring tags of the same commit; empty without one
+func (d *DefaultBumper) olderDescendant(ctx context.Context, tag string, older []TaggedVersion) (string, error) {
+	tags, err := d.Git.TagsContaining(ctx, tag)
+	if err != nil {
+		return "", err
+	}
+	contains := map[string]bool{}
+	for _, t := range tags {
+		contains[t] = true
+	}
+
+	var commit string
+	for i := len(older) - 1; i >= 0; i-- {
+		if !contains[older[i].Tag] {
+			continue
+		}
+		// Tags of the same commit are only resolved when they might be one
+		if commit == "" {
+			if commit, err = d.Git.ResolveRef(ctx, tag); err != nil {
+				return "", err
+			}
+		}
+		olderCommit, err := d.Git.ResolveRef(ctx, older[i].Tag)
+		if err != nil {
+			return "", err
+		}
+		if olderCommit != commit {
+			return older[i].Tag, nil
+		}
+	}
+	return "", nil
+}
+
+// looksLikeVersion reports whether a tag without its prefix starts like a
+// version (e.g. "1.2" or "v1.2.3."), unlike tags such as "latest"
+func looksLikeVersion(s string) bool {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V")
+	return s != "" && s[0] >= '0' && s[0] <= '9'
+}
@@ -0,0 +1,77 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version:
+// Revision:
+// Build Date:
+// Built By:
+
+package bumper
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// ProblemUnparsable is a Problem of type unparsable.
+	ProblemUnparsable Problem = "unparsable"
+	// ProblemDuplicate is a Problem of type duplicate.
+	ProblemDuplicate Problem = "duplicate"
+	// ProblemOrder is a Problem of type order.
+	ProblemOrder Problem = "order"
+)
+
+var ErrInvalidProblem = fmt.Errorf("not a valid Problem, try [%s]", strings.Join(_ProblemNames, ", "))
+
+var _ProblemNames = []string{
+	string(ProblemUnparsable),
+	string(ProblemDuplicate),
+	string(ProblemOrder),
+}
+
+// ProblemNames returns a list of possible string values of Problem.
+func ProblemNames() []string {
+	tmp := make([]string, len(_ProblemNames))
+	copy(tmp, _ProblemNames)
+	return tmp
+}
+
+// String implements the Stringer interface.
+func (x Problem) String() string {
+	return string(x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x Problem) IsValid() bool {
+	_, err := ParseProblem(string(x))
+	return err == nil
+}
+
+var _ProblemValue = map[string]Problem{
+	"unparsable": ProblemUnparsable,
+	"duplicate":  ProblemDuplicate,
+	"order":      ProblemOrder,
+}
+
+// ParseProblem attempts to convert a string to a Problem.
+func ParseProblem(name string) (Problem, error) {
+	if x, ok := _ProblemValue[name]; ok {
+		return x, nil
+	}
+	return Problem(""), fmt.Errorf("%s is %w", name, ErrInvalidProblem)
+}
+
+// MarshalText implements the text marshaller method.
+func (x Problem) MarshalText() ([]byte, error) {
+	return []byte(string(x)), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *Problem) UnmarshalText(text []byte) error {
+	tmp, err := ParseProblem(string(text))
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
@@ -0,0 +1,57 @@
+package bumper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultTagMessage is the message of annotated tags without a template
+const DefaultTagMessage = "{{.Tag}}"
+
+// TagInfo is the data available to tag message templates, e.g.
+// "{{.Tag}}: {{.Field}} bump from {{.Previous}}"
+type TagInfo struct {
+	// Tag is the new tag and Version its version without the prefix
+	Tag, Version string
+	// Previous is the tag of the latest version, empty without one
+	Previous string
+	// Field is the bumped field
+	Field string
+	// Commits holds the subjects of the commits since Previous, newest first
+	Commits []string
+}
+
+// RenderTagMessage executes a tag message template
+func RenderTagMessage(text string, info TagInfo) (string, error) {
+	tmpl, err := template.New("message").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parsing tag message template: %w", err)
+	}
+	var sb strings.Builder
+	if err = tmpl.Execute(&sb, info); err != nil {
+		return "", fmt.Errorf("executing tag message template: %w", err)
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// tagMessage renders the message of an annotated tag of ref, falling back
+// to the tag itself when the message is empty. The commits are only listed
+// for templates that refer to them, as the first tag walks the whole history.
+func (d *DefaultBumper) tagMessage(ctx context.Context, text, ref string, info TagInfo) (string, error) {
+	if text == "" {
+		text = DefaultTagMessage
+	}
+	var err error
+	if strings.Contains(text, ".Commits") {
+		if info.Commits, err = d.Git.CommitSubjects(ctx, info.Previous, ref); err != nil {
+			return "", err
+		}
+	}
+	message, err := RenderTagMessage(text, info)
+	if message == "" {
+		message = info.Tag
+	}
+	return message, err
+}
@@ -0,0 +1,34 @@
+package bumper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTagMessage(t *testing.T) {
+	info := TagInfo{
+		Tag:      "v1.4.0",
+		Version:  "1.4.0",
+		Previous: "v1.3.2",
+		Field:    "minor",
+		Commits:  []string{"[minor] add feature", "fix typo"},
+	}
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{DefaultTagMessage, "v1.4.0"},
+		{"{{.Tag}}: {{.Field}} bump from {{.Previous}}", "v1.4.0: minor bump from v1.3.2"},
+		{"Release {{.Version}}\n\n{{range .Commits}}- {{.}}\n{{end}}", "Release 1.4.0\n\n- [minor] add feature\n- fix typo"},
+	}
+	for _, test := range tests {
+		actual, err := RenderTagMessage(test.template, info)
+		require.NoError(t, err)
+		assert.Equalf(t, test.expected, actual, "RenderTagMessage(%q) = %q, want %q", test.template, actual, test.expected)
+	}
+
+	_, err := RenderTagMessage("{{.Tag", info)
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+package bumper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// BuildInfo is the data available to build metadata templates, e.g.
+// "sd.{{.Env.SD_BUILD_ID}}.g{{.ShortCommit}}"
+type BuildInfo struct {
+	// Commit is the SHA of HEAD and ShortCommit its abbreviated form
+	Commit, ShortCommit string
+	// Date is the current time in UTC, e.g. {{.Date.Format "20060102"}}
+	Date time.Time
+	// Env holds the environment variables
+	Env map[string]string
+}
+
+// RenderBuildMetadata executes a build metadata template. Characters that
+// are not allowed in SemVer build metadata are replaced with "-" and empty
+// identifiers (e.g. from unset environment variables) are dropped.
+func RenderBuildMetadata(text string, info BuildInfo) (string, error) {
+	tmpl, err := template.New("build-metadata").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parsing build metadata template: %w", err)
+	}
+	var sb strings.Builder
+	if err = tmpl.Execute(&sb, info); err != nil {
+		return "", fmt.Errorf("executing build metadata template: %w", err)
+	}
+	return sanitizeBuildMetadata(sb.String()), nil
+}
+
+// sanitizeBuildMetadata turns s into dot-separated [0-9A-Za-z-] identifiers
+func sanitizeBuildMetadata(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-', r == '.':
+			return r
+		}
+		return '-'
+	}, s)
+
+	var ids []string
+	for _, id := range strings.Split(s, ".") {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return strings.Join(ids, ".")
+}
+
+// BuildMetadata renders a build metadata template for the commit ref points
+// at
+func (d *DefaultBumper) BuildMetadata(ctx context.Context, text, ref string) (string, error) {
+	info := BuildInfo{
+		Date: time.Now().UTC(),
+		Env:  map[string]string{},
+	}
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		info.Env[k] = v
+	}
+
+	var err error
+	if info.Commit, err = d.Git.LastCommit(ctx, ref, false); err != nil {
+		return "", fmt.Errorf("getting current commit sha %w", err)
+	}
+	if info.ShortCommit, err = d.Git.LastCommit(ctx, ref, true); err != nil {
+		return "", fmt.Errorf("getting current commit sha %w", err)
+	}
+	return RenderBuildMetadata(text, info)
+}
@@ -0,0 +1,42 @@
+package bumper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderBuildMetadata(t *testing.T) {
+	info := BuildInfo{
+		Commit:      "9d8ceaaa28f0563e52e1edf3eaae72c814aa1102",
+		ShortCommit: "9d8ceaa",
+		Date:        time.Date(2024, time.March, 5, 10, 0, 0, 0, time.UTC),
+		Env:         map[string]string{"SD_BUILD_ID": "1234", "BRANCH": "feature/x_y"},
+	}
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"sd.{{.Env.SD_BUILD_ID}}.g{{.ShortCommit}}", "sd.1234.g9d8ceaa"},
+		{"{{.Commit}}", "9d8ceaaa28f0563e52e1edf3eaae72c814aa1102"},
+		{`{{.Date.Format "20060102"}}`, "20240305"},
+		{"{{.Env.BRANCH}}", "feature-x-y"},
+		{"sd.{{.Env.UNSET}}.g{{.ShortCommit}}", "sd.g9d8ceaa"},
+		{"..build..", "build"},
+	}
+	for _, test := range tests {
+		actual, err := RenderBuildMetadata(test.template, info)
+		require.NoError(t, err)
+		assert.Equalf(t, test.expected, actual, "RenderBuildMetadata(%q) = %q, want %q", test.template, actual, test.expected)
+	}
+}
+
+func TestRenderBuildMetadataErrors(t *testing.T) {
+	_, err := RenderBuildMetadata("{{.Commit", BuildInfo{})
+	assert.Error(t, err)
+
+	_, err = RenderBuildMetadata("{{.Unknown}}", BuildInfo{})
+	assert.Error(t, err)
+}
@@ -5,6 +5,7 @@
 package bumper
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,11 +35,26 @@ func (m *MockBumper) EXPECT() *MockBumperMockRecorder {
 	return m.recorder
 }
 
+// BuildMetadata mocks base method.
+func (m *MockBumper) BuildMetadata(ctx context.Context, template, ref string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuildMetadata", ctx, template, ref)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuildMetadata indicates an expected call of BuildMetadata.
+func (mr *MockBumperMockRecorder) BuildMetadata(ctx, template, ref interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildMetadata", reflect.TypeOf((*MockBumper)(nil).BuildMetadata), ctx, template, ref)
+}
+
 // Bump mocks base method.
-func (m *MockBumper) Bump(arg0 ...BumpOption) error {
+func (m *MockBumper) Bump(ctx context.Context, options ...BumpOption) error {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range arg0 {
+	varargs := []interface{}{ctx}
+	for _, a := range options {
 		varargs = append(varargs, a)
 	}
 	ret := m.ctrl.Call(m, "Bump", varargs...)
@@ -47,37 +63,97 @@ func (m *MockBumper) Bump(arg0 ...BumpOption) error {
 }
 
 // Bump indicates an expected call of Bump.
-func (mr *MockBumperMockRecorder) Bump(arg0 ...interface{}) *gomock.Call {
+func (mr *MockBumperMockRecorder) Bump(ctx interface{}, options ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Bump", reflect.TypeOf((*MockBumper)(nil).Bump), arg0...)
+	varargs := append([]interface{}{ctx}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Bump", reflect.TypeOf((*MockBumper)(nil).Bump), varargs...)
 }
 
 // LatestVersion mocks base method.
-func (m *MockBumper) LatestVersion(prefix string, merged bool) (version.Version, error) {
+func (m *MockBumper) LatestVersion(ctx context.Context, prefix, ref string, merged bool) (version.Version, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "LatestVersion", prefix, merged)
+	ret := m.ctrl.Call(m, "LatestVersion", ctx, prefix, ref, merged)
 	ret0, _ := ret[0].(version.Version)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // LatestVersion indicates an expected call of LatestVersion.
-func (mr *MockBumperMockRecorder) LatestVersion(prefix, merged interface{}) *gomock.Call {
+func (mr *MockBumperMockRecorder) LatestVersion(ctx, prefix, ref, merged interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LatestVersion", reflect.TypeOf((*MockBumper)(nil).LatestVersion), ctx, prefix, ref, merged)
+}
+
+// Lint mocks base method.
+func (m *MockBumper) Lint(ctx context.Context, prefix, ref string, merged bool) ([]LintIssue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lint", ctx, prefix, ref, merged)
+	ret0, _ := ret[0].([]LintIssue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lint indicates an expected call of Lint.
+func (mr *MockBumperMockRecorder) Lint(ctx, prefix, ref, merged interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lint", reflect.TypeOf((*MockBumper)(nil).Lint), ctx, prefix, ref, merged)
+}
+
+// Parse mocks base method.
+func (m *MockBumper) Parse(prefix, tag string) (version.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Parse", prefix, tag)
+	ret0, _ := ret[0].(version.Version)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Parse indicates an expected call of Parse.
+func (mr *MockBumperMockRecorder) Parse(prefix, tag interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Parse", reflect.TypeOf((*MockBumper)(nil).Parse), prefix, tag)
+}
+
+// TaggedVersions mocks base method.
+func (m *MockBumper) TaggedVersions(ctx context.Context, prefix, ref string, merged bool) ([]TaggedVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TaggedVersions", ctx, prefix, ref, merged)
+	ret0, _ := ret[0].([]TaggedVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TaggedVersions indicates an expected call of TaggedVersions.
+func (mr *MockBumperMockRecorder) TaggedVersions(ctx, prefix, ref, merged interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaggedVersions", reflect.TypeOf((*MockBumper)(nil).TaggedVersions), ctx, prefix, ref, merged)
+}
+
+// VerifyTag mocks base method.
+func (m *MockBumper) VerifyTag(ctx context.Context, tag string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyTag", ctx, tag)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyTag indicates an expected call of VerifyTag.
+func (mr *MockBumperMockRecorder) VerifyTag(ctx, tag interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LatestVersion", reflect.TypeOf((*MockBumper)(nil).LatestVersion), prefix, merged)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyTag", reflect.TypeOf((*MockBumper)(nil).VerifyTag), ctx, tag)
 }
 
 // Versions mocks base method.
-func (m *MockBumper) Versions(prefix string, merged bool) (version.List, error) {
+func (m *MockBumper) Versions(ctx context.Context, prefix, ref string, merged bool) (version.List, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Versions", prefix, merged)
+	ret := m.ctrl.Call(m, "Versions", ctx, prefix, ref, merged)
 	ret0, _ := ret[0].(version.List)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Versions indicates an expected call of Versions.
-func (mr *MockBumperMockRecorder) Versions(prefix, merged interface{}) *gomock.Call {
+func (mr *MockBumperMockRecorder) Versions(ctx, prefix, ref, merged interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Versions", reflect.TypeOf((*MockBumper)(nil).Versions), prefix, merged)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Versions", reflect.TypeOf((*MockBumper)(nil).Versions), ctx, prefix, ref, merged)
 }
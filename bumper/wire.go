@@ -6,6 +6,7 @@ package bumper
 import (
 	"github.com/google/wire"
 	"github.com/screwdriver-cd/gitversion/git"
+	"github.com/screwdriver-cd/gitversion/version"
 )
 
 var DefaultSet = wire.NewSet(
@@ -18,6 +19,19 @@ var buildSet = wire.NewSet(
 	git.DefaultSet,
 )
 
-func NewBumper() Bumper {
+var nativeBuildSet = wire.NewSet(
+	DefaultSet,
+	git.NativeSet,
+)
+
+// NewBumper returns a Bumper running the git command in the repository at
+// dir, the working directory when empty, and a function releasing it
+func NewBumper(scheme version.Scheme, dir string) (Bumper, func()) {
 	panic(wire.Build(buildSet))
 }
+
+// NewNativeBumper returns a Bumper using git.NativeGit instead of the git
+// command, and a function closing the repository
+func NewNativeBumper(scheme version.Scheme, dir string) (Bumper, func()) {
+	panic(wire.Build(nativeBuildSet))
+}
@@ -9,19 +9,38 @@ package bumper
 import (
 	"github.com/google/wire"
 	"github.com/screwdriver-cd/gitversion/git"
+	"github.com/screwdriver-cd/gitversion/version"
 )
 
 // Injectors from wire.go:
 
-func NewBumper() Bumper {
+// NewBumper returns a Bumper running the git command in the repository at
+// dir, the working directory when empty, and a function releasing it
+func NewBumper(scheme version.Scheme, dir string) (Bumper, func()) {
 	defaultCmdRunner := &git.DefaultCmdRunner{}
 	defaultGit := &git.DefaultGit{
 		CmdRunner: defaultCmdRunner,
+		Dir:       dir,
 	}
 	defaultBumper := &DefaultBumper{
-		Git: defaultGit,
+		Git:    defaultGit,
+		Scheme: scheme,
+	}
+	return defaultBumper, func() {
+	}
+}
+
+// NewNativeBumper returns a Bumper using git.NativeGit instead of the git
+// command, and a function closing the repository
+func NewNativeBumper(scheme version.Scheme, dir string) (Bumper, func()) {
+	nativeGit, cleanup := git.ProvideNativeGit(dir)
+	defaultBumper := &DefaultBumper{
+		Git:    nativeGit,
+		Scheme: scheme,
+	}
+	return defaultBumper, func() {
+		cleanup()
 	}
-	return defaultBumper
 }
 
 // wire.go:
@@ -31,3 +50,7 @@ var DefaultSet = wire.NewSet(wire.Struct(new(DefaultBumper), "*"), wire.Bind(new
 var buildSet = wire.NewSet(
 	DefaultSet, git.DefaultSet,
 )
+
+var nativeBuildSet = wire.NewSet(
+	DefaultSet, git.NativeSet,
+)
@@ -0,0 +1,122 @@
+package git
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type (
+	// Error is a failed git command with the message git wrote to stderr.
+	// It matches the sentinel errors below with errors.Is when the message
+	// describes one of their conditions.
+	Error struct {
+		// Args are the arguments of the git command
+		Args []string
+		// Stderr is what git wrote to stderr
+		Stderr string
+		// Err is the error of the command, e.g. an *exec.ExitError or the
+		// error of its context
+		Err error
+	}
+)
+
+var (
+	// ErrNotARepository is returned when the directory is not in a git
+	// repository
+	ErrNotARepository = errors.New("not a git repository")
+	// ErrTagExists is returned when creating a tag that already exists
+	ErrTagExists = errors.New("tag already exists")
+	// ErrNoCommits is returned when HEAD is needed but the repository has no
+	// commits yet
+	ErrNoCommits = errors.New("the repository has no commits")
+	// ErrUnknownRevision is returned when a ref (e.g. a tag) doesn't exist
+	ErrUnknownRevision = errors.New("unknown revision")
+	// ErrRefLocked is returned when a ref can't be updated because its lock
+	// file exists, e.g. while another git process is running
+	ErrRefLocked = errors.New("ref is locked")
+	// ErrPushRejected is returned when the remote rejects a tag, e.g.
+	// because it already has a tag with the same name
+	ErrPushRejected = errors.New("push rejected")
+	// ErrShallowRepository is returned when the history of a shallow clone
+	// is too short to find what is needed, e.g. a version tag
+	ErrShallowRepository = errors.New("the repository is a shallow clone")
+
+	tagExistsPattern = regexp.MustCompile(`tag '.*' already exists`)
+	// badRevisionPatterns match the messages of commands given a revision
+	// that doesn't resolve, which is HEAD in a repository without commits
+	badRevisionPatterns = []string{
+		"Needed a single revision",
+		"unknown revision",
+		"malformed object name",
+		"Failed to resolve",
+		"Not a valid object name",
+		"bad revision",
+	}
+)
+
+// Error returns the messages of git, or the error of the command when git
+// didn't write any
+func (e *Error) Error() string {
+	var messages []string
+	for _, line := range strings.Split(e.Stderr, "\n") {
+		line = strings.TrimSpace(line)
+		if message, ok := strings.CutPrefix(line, "fatal: "); ok {
+			messages = append(messages, message)
+		} else if message, ok := strings.CutPrefix(line, "error: "); ok {
+			messages = append(messages, message)
+		} else if strings.HasPrefix(line, "!") {
+			// Status of a ref that wasn't pushed
+			messages = append(messages, line)
+		}
+	}
+	if len(messages) == 0 {
+		if line, _, _ := strings.Cut(strings.TrimSpace(e.Stderr), "\n"); line != "" {
+			messages = append(messages, line)
+		} else if e.Err != nil {
+			messages = append(messages, e.Err.Error())
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the error of the command and the sentinel error matching
+// the message of git, if any
+func (e *Error) Unwrap() []error {
+	errs := []error{e.Err}
+	if sentinel := e.sentinel(); sentinel != nil {
+		errs = append(errs, sentinel)
+	}
+	return errs
+}
+
+// sentinel returns the sentinel error describing the message of git
+func (e *Error) sentinel() error {
+	switch {
+	case strings.Contains(e.Stderr, "not a git repository"):
+		return ErrNotARepository
+	case strings.Contains(e.Stderr, "does not have any commits yet"):
+		return ErrNoCommits
+	case strings.Contains(e.Stderr, "[rejected]") || strings.Contains(e.Stderr, "[remote rejected]"):
+		return ErrPushRejected
+	case tagExistsPattern.MatchString(e.Stderr):
+		return ErrTagExists
+	case strings.Contains(e.Stderr, "cannot lock ref") || strings.Contains(e.Stderr, ".lock': File exists"):
+		return ErrRefLocked
+	}
+	for _, pattern := range badRevisionPatterns {
+		if !strings.Contains(e.Stderr, pattern) {
+			continue
+		} else if slices.ContainsFunc(e.Args, isHead) {
+			return ErrNoCommits
+		}
+		return ErrUnknownRevision
+	}
+	return nil
+}
+
+// isHead reports whether a revision argument is HEAD itself
+func isHead(arg string) bool {
+	return arg == "HEAD" || arg == "HEAD^{commit}"
+}
@@ -0,0 +1,158 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestError(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 128").Run()
+	require.Error(t, exitErr)
+
+	tests := []struct {
+		name     string
+		args     []string
+		stderr   string
+		message  string
+		sentinel error
+	}{
+		{
+			name:     "not a repository",
+			args:     []string{"tag"},
+			stderr:   "fatal: not a git repository (or any of the parent directories): .git\n",
+			message:  "not a git repository (or any of the parent directories): .git",
+			sentinel: ErrNotARepository,
+		},
+		{
+			name:     "tag exists",
+			args:     []string{"tag", "v1.0.0", "HEAD^{commit}"},
+			stderr:   "fatal: tag 'v1.0.0' already exists\n",
+			message:  "tag 'v1.0.0' already exists",
+			sentinel: ErrTagExists,
+		},
+		{
+			name:     "no commits",
+			args:     []string{"rev-parse", "--verify", "HEAD^{commit}"},
+			stderr:   "fatal: Needed a single revision\n",
+			message:  "Needed a single revision",
+			sentinel: ErrNoCommits,
+		},
+		{
+			name:     "no commits on a branch",
+			args:     []string{"log", "-1", "--pretty=%B", "HEAD"},
+			stderr:   "fatal: your current branch 'main' does not have any commits yet\n",
+			message:  "your current branch 'main' does not have any commits yet",
+			sentinel: ErrNoCommits,
+		},
+		{
+			name:     "unknown ref",
+			args:     []string{"rev-parse", "--verify", "nope^{commit}"},
+			stderr:   "fatal: Needed a single revision\n",
+			message:  "Needed a single revision",
+			sentinel: ErrUnknownRevision,
+		},
+		{
+			name: "ref locked",
+			args: []string{"tag", "v1.0.0", "HEAD^{commit}"},
+			stderr: "fatal: cannot lock ref 'refs/tags/v1.0.0': Unable to create '/repo/.git/refs/tags/v1.0.0.lock': File exists.\n\n" +
+				"Another git process seems to be running in this repository.\n",
+			message:  "cannot lock ref 'refs/tags/v1.0.0': Unable to create '/repo/.git/refs/tags/v1.0.0.lock': File exists.",
+			sentinel: ErrRefLocked,
+		},
+		{
+			name:     "push rejected",
+			args:     []string{"push", "origin", "refs/tags/v1.0.0"},
+			stderr:   "To /remote\n ! [rejected]        v1.0.0 -> v1.0.0 (already exists)\nerror: failed to push some refs to '/remote'\n",
+			message:  "! [rejected]        v1.0.0 -> v1.0.0 (already exists); failed to push some refs to '/remote'",
+			sentinel: ErrPushRejected,
+		},
+		{
+			name:     "other message",
+			args:     []string{"fetch", "--tags", "origin"},
+			stderr:   "ssh: Could not resolve hostname example.invalid\n",
+			message:  "ssh: Could not resolve hostname example.invalid",
+			sentinel: nil,
+		},
+		{
+			name:     "no message",
+			args:     []string{"rev-parse", "--verify", "--quiet", "nope^{commit}"},
+			stderr:   "",
+			message:  "exit status 128",
+			sentinel: nil,
+		},
+	}
+	sentinels := []error{ErrNotARepository, ErrTagExists, ErrNoCommits, ErrUnknownRevision, ErrRefLocked, ErrPushRejected}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &Error{Args: tt.args, Stderr: tt.stderr, Err: exitErr}
+			assert.EqualError(t, err, tt.message)
+			for _, sentinel := range sentinels {
+				assert.Equalf(t, sentinel == tt.sentinel, errors.Is(err, sentinel), "errors.Is(%v)", sentinel)
+			}
+			var target *exec.ExitError
+			assert.ErrorAs(t, err, &target)
+		})
+	}
+}
+
+// TestErrorsOfGit checks the errors of both backends in real repositories
+func TestErrorsOfGit(t *testing.T) {
+	dir := repoForTest(t)
+	empty := t.TempDir()
+	out, err := exec.Command("git", "init", "-q", empty).CombinedOutput()
+	require.NoErrorf(t, err, "git init: %s", out)
+
+	backends := map[string]func(dir string) Git{
+		"git": func(dir string) Git {
+			return &DefaultGit{CmdRunner: &DefaultCmdRunner{}, Dir: dir}
+		},
+		"native": func(dir string) Git {
+			return &NativeGit{Dir: dir}
+		},
+	}
+	for name, newGit := range backends {
+		t.Run(name, func(t *testing.T) {
+			_, err := newGit(t.TempDir()).Tags(t.Context(), "HEAD", true)
+			assert.ErrorIs(t, err, ErrNotARepository)
+
+			g := newGit(empty)
+			_, err = g.LastCommit(t.Context(), "HEAD", false)
+			assert.ErrorIs(t, err, ErrNoCommits)
+			assert.ErrorIs(t, g.Tag(t.Context(), "v1.0.0", "HEAD"), ErrNoCommits)
+
+			g = newGit(dir)
+			_, err = g.LastCommit(t.Context(), "nope", false)
+			assert.ErrorIs(t, err, ErrUnknownRevision)
+			assert.NotErrorIs(t, err, ErrNoCommits)
+			_, err = g.ResolveRef(t.Context(), "nope")
+			assert.ErrorIs(t, err, ErrUnknownRevision)
+			assert.ErrorIs(t, g.Tag(t.Context(), "v1.0.0", "HEAD"), ErrTagExists)
+
+			lock := filepath.Join(dir, ".git", "refs", "tags", "v9.0.0.lock")
+			require.NoError(t, os.WriteFile(lock, nil, 0o644))
+			defer os.Remove(lock)
+			assert.ErrorIs(t, g.Tag(t.Context(), "v9.0.0", "HEAD"), ErrRefLocked)
+		})
+	}
+}
+
+func TestErrorsOfGitTranslated(t *testing.T) {
+	repoForTest(t)
+	// LANGUAGE selects the translations unless the locale is C
+	t.Setenv("LANGUAGE", "de")
+	t.Setenv("LANG", "C.UTF-8")
+	t.Setenv("LC_ALL", "")
+
+	g := &DefaultGit{CmdRunner: &DefaultCmdRunner{}}
+	assert.ErrorIs(t, g.Tag(t.Context(), "v1.0.0", "HEAD"), ErrTagExists)
+	_, err := g.ResolveRef(t.Context(), "nope")
+	assert.ErrorIs(t, err, ErrUnknownRevision)
+	_, err = g.LastCommit(t.Context(), "nope", false)
+	assert.ErrorIs(t, err, ErrUnknownRevision)
+}
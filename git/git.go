@@ -1,45 +1,110 @@
 package git
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
+	"time"
 )
 
 //go:generate go run github.com/golang/mock/mockgen -source $GOFILE -destination mock_$GOFILE -package $GOPACKAGE
 
 type (
 	CmdRunner interface {
-		Run(*exec.Cmd) error
-		Output(*exec.Cmd) ([]byte, error)
+		Run(context.Context, *exec.Cmd) error
+		Output(context.Context, *exec.Cmd) ([]byte, error)
 	}
 	DefaultCmdRunner struct{}
 
 	Git interface {
-		LastCommit(short bool) (string, error)
-		LastCommitMessage() (string, error)
-		Tag(tag string) error
-		Tags(merged bool) ([]string, error)
-		Tagged() (bool, error)
+		LastCommit(ctx context.Context, ref string, short bool) (string, error)
+		LastCommitMessage(ctx context.Context, ref string) (string, error)
+		Tag(ctx context.Context, tag, ref string, options ...TagOption) error
+		Tags(ctx context.Context, ref string, merged bool) ([]string, error)
+		TagsPointingAt(ctx context.Context, ref string) ([]string, error)
+		TagsContaining(ctx context.Context, ref string) ([]string, error)
+		ResolveRef(ctx context.Context, ref string) (string, error)
+		CommitSubjects(ctx context.Context, since, ref string) ([]string, error)
+		VerifyTag(ctx context.Context, tag string) error
+		DeleteTag(ctx context.Context, tag string) error
+		Push(ctx context.Context, remote, tag string) error
+		FetchTags(ctx context.Context, remote string) error
+		IsShallow(ctx context.Context) (bool, error)
+		Deepen(ctx context.Context, remote string, depth int) error
 	}
 	DefaultGit struct {
 		CmdRunner CmdRunner
+		// Dir is the directory of the repository, the working directory when
+		// empty
+		Dir string
 	}
+
+	// TagOptions configure the tags created by Git.Tag
+	TagOptions struct {
+		// Message makes the tag an annotated tag
+		Message string
+		// Sign creates a signed tag, using SigningKey when set and the
+		// default key otherwise
+		Sign       bool
+		SigningKey string
+		// SignFormat overrides the gpg.format config (openpgp, x509 or ssh)
+		SignFormat string
+	}
+	TagOption func(*TagOptions)
 )
 
+// NewTagOptions applies options to the default TagOptions
+func NewTagOptions(options ...TagOption) *TagOptions {
+	ret := &TagOptions{}
+	for _, opt := range options {
+		opt(ret)
+	}
+	return ret
+}
+
+// WithMessage creates an annotated tag with a message
+func WithMessage(message string) TagOption {
+	return func(options *TagOptions) {
+		options.Message = message
+	}
+}
+
+// commandWaitDelay bounds the wait for an interrupted command to exit
+const commandWaitDelay = time.Second
+
 var (
 	_ Git       = &DefaultGit{}
 	_ CmdRunner = &DefaultCmdRunner{}
 )
 
-// Tags returns the list of git tags as a string slice
-func (g *DefaultGit) Tags(merged bool) ([]string, error) {
+// WithSign creates a signed tag with a key, or the default key when empty
+func WithSign(key string) TagOption {
+	return func(options *TagOptions) {
+		options.Sign = true
+		options.SigningKey = key
+	}
+}
+
+// WithSignFormat sets the signature format of signed tags (openpgp, x509 or ssh)
+func WithSignFormat(format string) TagOption {
+	return func(options *TagOptions) {
+		options.SignFormat = format
+	}
+}
+
+// Tags returns the list of git tags as a string slice; only the tags merged
+// into ref when merged is set
+func (g *DefaultGit) Tags(ctx context.Context, ref string, merged bool) ([]string, error) {
 	args := []string{"tag"}
 	if merged {
-		args = append(args, "--merged")
+		args = append(args, "--merged", ref)
 	}
-	cmd := exec.Command("git", args...)
-	out, err := g.CmdRunner.Output(cmd)
+	cmd := g.command(ctx, args...)
+	out, err := g.CmdRunner.Output(ctx, cmd)
 	if err != nil {
 		return nil, fmt.Errorf("fetching git tags: %w", err)
 	}
@@ -49,10 +114,63 @@ func (g *DefaultGit) Tags(merged bool) ([]string, error) {
 	return lines, nil
 }
 
-// Tag calls git to create a new tag from a string
-func (g *DefaultGit) Tag(tag string) error {
-	cmd := exec.Command("git", "tag", tag)
-	_, err := g.CmdRunner.Output(cmd)
+// TagsPointingAt returns the tags of the commit ref points at
+func (g *DefaultGit) TagsPointingAt(ctx context.Context, ref string) ([]string, error) {
+	cmd := g.command(ctx, "tag", "--points-at", ref+"^{commit}")
+	out, err := g.CmdRunner.Output(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("fetching tags of %s: %w", ref, err)
+	}
+
+	trimmed := strings.TrimSpace(string(out))
+	if trimmed == "" {
+		return nil, nil
+	}
+	return strings.Split(trimmed, "\n"), nil
+}
+
+// TagsContaining returns the tags of the commit ref points at and of its
+// descendants
+func (g *DefaultGit) TagsContaining(ctx context.Context, ref string) ([]string, error) {
+	cmd := g.command(ctx, "tag", "--contains", ref+"^{commit}")
+	out, err := g.CmdRunner.Output(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("fetching tags containing %s: %w", ref, err)
+	}
+
+	trimmed := strings.TrimSpace(string(out))
+	if trimmed == "" {
+		return nil, nil
+	}
+	return strings.Split(trimmed, "\n"), nil
+}
+
+// Tag calls git to create a new tag of the commit ref points at
+func (g *DefaultGit) Tag(ctx context.Context, tag, ref string, options ...TagOption) error {
+	opts := NewTagOptions(options...)
+	var args []string
+	if opts.SignFormat != "" {
+		args = append(args, "-c", "gpg.format="+opts.SignFormat)
+	}
+	args = append(args, "tag")
+	switch {
+	case opts.SigningKey != "":
+		args = append(args, "-u", opts.SigningKey)
+	case opts.Sign:
+		args = append(args, "-s")
+	case opts.Message != "":
+		args = append(args, "-a")
+	}
+	if message := opts.Message; message != "" || opts.Sign {
+		// Signed tags are annotated tags, which need a message
+		if message == "" {
+			message = tag
+		}
+		args = append(args, "-m", message)
+	}
+	args = append(args, tag, ref+"^{commit}")
+	cmd := g.command(ctx, args...)
+	_, err := g.CmdRunner.Output(ctx, cmd)
 	if err != nil {
 		return fmt.Errorf("tagging the commit in git: %w", err)
 	}
@@ -60,16 +178,86 @@ func (g *DefaultGit) Tag(tag string) error {
 	return nil
 }
 
-// LastCommit gets the last commit SHA
-func (g *DefaultGit) LastCommit(short bool) (string, error) {
+// DeleteTag deletes a local tag
+func (g *DefaultGit) DeleteTag(ctx context.Context, tag string) error {
+	cmd := g.command(ctx, "tag", "-d", tag)
+	_, err := g.CmdRunner.Output(ctx, cmd)
+	if err != nil {
+		return fmt.Errorf("deleting tag %s: %w", tag, err)
+	}
+
+	return nil
+}
+
+// Push pushes a single tag to a remote. The error matches ErrPushRejected
+// when the remote rejects it.
+func (g *DefaultGit) Push(ctx context.Context, remote, tag string) error {
+	cmd := g.command(ctx, "push", remote, "refs/tags/"+tag)
+	if _, err := g.CmdRunner.Output(ctx, cmd); err != nil {
+		return fmt.Errorf("pushing tag %s to %s: %w", tag, remote, err)
+	}
+
+	return nil
+}
+
+// FetchTags fetches the tags of a remote
+func (g *DefaultGit) FetchTags(ctx context.Context, remote string) error {
+	cmd := g.command(ctx, "fetch", "--tags", remote)
+	if _, err := g.CmdRunner.Output(ctx, cmd); err != nil {
+		return fmt.Errorf("fetching tags from %s: %w", remote, err)
+	}
+
+	return nil
+}
+
+// IsShallow returns true if the repository is a shallow clone, whose history
+// is cut at some commits
+func (g *DefaultGit) IsShallow(ctx context.Context) (bool, error) {
+	cmd := g.command(ctx, "rev-parse", "--is-shallow-repository")
+	out, err := g.CmdRunner.Output(ctx, cmd)
+	if err != nil {
+		return false, fmt.Errorf("checking for a shallow clone: %w", err)
+	}
+
+	return strings.TrimSpace(string(out)) == "true", nil
+}
+
+// Deepen fetches depth more commits of the history of a shallow clone from a
+// remote, or all of it when depth is 0, along with the tags of the remote
+// (which clones made with --no-tags lack)
+func (g *DefaultGit) Deepen(ctx context.Context, remote string, depth int) error {
+	arg := "--unshallow"
+	if depth > 0 {
+		arg = fmt.Sprintf("--deepen=%d", depth)
+	}
+	cmd := g.command(ctx, "fetch", "--tags", arg, remote)
+	if _, err := g.CmdRunner.Output(ctx, cmd); err != nil {
+		return fmt.Errorf("deepening the clone from %s: %w", remote, err)
+	}
+
+	return nil
+}
+
+// VerifyTag checks the signature of a tag
+func (g *DefaultGit) VerifyTag(ctx context.Context, tag string) error {
+	cmd := g.command(ctx, "tag", "-v", tag)
+	if err := g.CmdRunner.Run(ctx, cmd); err != nil {
+		return fmt.Errorf("verifying tag %s: %w", tag, err)
+	}
+
+	return nil
+}
+
+// LastCommit gets the SHA of the commit ref points at
+func (g *DefaultGit) LastCommit(ctx context.Context, ref string, short bool) (string, error) {
 	var cmd *exec.Cmd
 
 	if short {
-		cmd = exec.Command("git", "rev-parse", "--short", "HEAD")
+		cmd = g.command(ctx, "rev-parse", "--short", "--verify", ref+"^{commit}")
 	} else {
-		cmd = exec.Command("git", "rev-parse", "HEAD")
+		cmd = g.command(ctx, "rev-parse", "--verify", ref+"^{commit}")
 	}
-	out, err := g.CmdRunner.Output(cmd)
+	out, err := g.CmdRunner.Output(ctx, cmd)
 	if err != nil {
 		return "", fmt.Errorf("fetching git commit: %w", err)
 	}
@@ -78,36 +266,112 @@ func (g *DefaultGit) LastCommit(short bool) (string, error) {
 	return trimmed, nil
 }
 
-// LastCommitMessage gets the last commit message
-func (g *DefaultGit) LastCommitMessage() (string, error) {
-	cmd := exec.Command("git", "log", "-1", "--pretty=%B")
-	out, err := g.CmdRunner.Output(cmd)
-	if err != nil {
-		return "", fmt.Errorf("fetching git commit message: %w", err)
+// ResolveRef returns the SHA of the commit a ref (e.g. a tag) points at. The
+// error matches ErrUnknownRevision when the ref doesn't exist.
+func (g *DefaultGit) ResolveRef(ctx context.Context, ref string) (string, error) {
+	cmd := g.command(ctx, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	out, err := g.CmdRunner.Output(ctx, cmd)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		// --quiet exits with 1 and no message for unknown refs
+		return "", fmt.Errorf("resolving %s: %w", ref, ErrUnknownRevision)
+	} else if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", ref, err)
 	}
 
 	trimmed := strings.TrimSpace(string(out))
 	return trimmed, nil
 }
 
-// Tagged returns true if the specified commit has been tagged
-func (g *DefaultGit) Tagged() (bool, error) {
-	commit, err := g.LastCommit(false)
+// CommitSubjects returns the subjects of the commits since a ref (e.g. the
+// previous tag) up to ref, newest first; all commits up to ref when since is
+// empty
+func (g *DefaultGit) CommitSubjects(ctx context.Context, since, ref string) ([]string, error) {
+	// ^{commit} keeps the refs from being taken for paths
+	rev := ref + "^{commit}"
+	if since != "" {
+		rev = since + "^{commit}.." + rev
+	}
+	cmd := g.command(ctx, "log", "--format=%s", rev)
+	out, err := g.CmdRunner.Output(ctx, cmd)
 	if err != nil {
-		return false, fmt.Errorf("checking current tag: %w", err)
+		return nil, fmt.Errorf("fetching commit subjects: %w", err)
+	}
+
+	trimmed := strings.TrimSpace(string(out))
+	if trimmed == "" {
+		return nil, nil
 	}
-	cmd := exec.Command("git", "tag", "--contains", commit)
-	t, err := g.CmdRunner.Output(cmd)
+	return strings.Split(trimmed, "\n"), nil
+}
+
+// LastCommitMessage gets the message of the commit ref points at
+func (g *DefaultGit) LastCommitMessage(ctx context.Context, ref string) (string, error) {
+	cmd := g.command(ctx, "log", "-1", "--pretty=%B", ref+"^{commit}")
+	out, err := g.CmdRunner.Output(ctx, cmd)
 	if err != nil {
-		return false, nil
+		return "", fmt.Errorf("fetching git commit message: %w", err)
 	}
-	return len(string(t)) > 0, nil
+
+	trimmed := strings.TrimSpace(string(out))
+	return trimmed, nil
 }
 
-func (d *DefaultCmdRunner) Run(cmd *exec.Cmd) error {
-	return cmd.Run()
+// command returns a git command run in the repository directory, which is
+// killed when the context is done
+func (g *DefaultGit) command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = g.Dir
+	// The sentinel errors are found in the messages of git, which must not
+	// be translated
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	// Interrupt git so that it removes its lock files, and kill it if it is
+	// still running (or a subprocess such as ssh holds its output) after the
+	// delay
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = commandWaitDelay
+	return cmd
 }
 
-func (d *DefaultCmdRunner) Output(cmd *exec.Cmd) ([]byte, error) {
-	return cmd.Output()
+// Run runs a command; failures are returned as an *Error with the message
+// git wrote to stderr
+func (d *DefaultCmdRunner) Run(ctx context.Context, cmd *exec.Cmd) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	if cmd.Stderr == nil {
+		cmd.Stderr = &stderr
+	}
+	err := cmd.Run()
+	return newError(ctx, cmd, stderr.Bytes(), err)
+}
+
+// Output runs a command and returns its standard output; failures are
+// returned as an *Error with the message git wrote to stderr
+func (d *DefaultCmdRunner) Output(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	out, err := cmd.Output()
+	var stderr []byte
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		stderr = exitErr.Stderr
+	}
+	return out, newError(ctx, cmd, stderr, err)
+}
+
+// newError returns the *Error of a failed command, or the context's error
+// when it was interrupted
+func newError(ctx context.Context, cmd *exec.Cmd, stderr []byte, err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return &Error{Args: cmd.Args[1:], Stderr: string(stderr), Err: err}
 }
@@ -1,11 +1,14 @@
 package git
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/screwdriver-cd/gitversion/testutil"
@@ -65,7 +68,7 @@ func gitCmdMatcher(args ...string) gomock.Matcher {
 func withGitTagOutput(output string, args ...string) CmdRunnerOption {
 	return func(runner *MockCmdRunner) {
 		runner.EXPECT().
-			Output(gitCmdMatcher(args...)).
+			Output(gomock.Any(), gitCmdMatcher(args...)).
 			Return([]byte(output), nil)
 	}
 }
@@ -80,7 +83,7 @@ func TestTags(t *testing.T) {
 	}
 	g := gitForTest(ctrl, withGitTagOutput(fakeTagsOutput, "tag"))
 
-	tags, err := g.Tags(false)
+	tags, err := g.Tags(t.Context(), "HEAD", false)
 	require.NoError(t, err)
 
 	require.Equal(t, len(expected), len(tags))
@@ -90,12 +93,22 @@ func TestTags(t *testing.T) {
 	}
 }
 
+func TestTagsMerged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	g := gitForTest(ctrl, withGitTagOutput("v1.0.1\nv1.2.1\n", "tag", "--merged", "release"))
+
+	tags, err := g.Tags(t.Context(), "release", true)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"v1.0.1", "v1.2.1"}, tags)
+}
+
 func TestLastCommitLong(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	expected := "9d8ceaaa28f0563e52e1edf3eaae72c814aa1102"
-	g := gitForTest(ctrl, withGitTagOutput(fakeHeadOutput, "rev-parse", "HEAD"))
+	g := gitForTest(ctrl, withGitTagOutput(fakeHeadOutput, "rev-parse", "--verify", "HEAD^{commit}"))
 
-	commit, err := g.LastCommit(false)
+	commit, err := g.LastCommit(t.Context(), "HEAD", false)
 	require.NoError(t, err)
 
 	assert.Equal(t, expected, commit)
@@ -104,9 +117,9 @@ func TestLastCommitLong(t *testing.T) {
 func TestLastCommitShort(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	expected := "9d8ceaa"
-	g := gitForTest(ctrl, withGitTagOutput(expected+"\n", "rev-parse", "--short", "HEAD"))
+	g := gitForTest(ctrl, withGitTagOutput(expected+"\n", "rev-parse", "--short", "--verify", "release^{commit}"))
 
-	commit, err := g.LastCommit(true)
+	commit, err := g.LastCommit(t.Context(), "release", true)
 	require.NoError(t, err)
 	if err != nil {
 		t.Errorf("LastCommit() error = %q, should be nil", err)
@@ -115,36 +128,290 @@ func TestLastCommitShort(t *testing.T) {
 	assert.Equal(t, expected, commit)
 }
 
+func TestResolveRef(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	exitErr := exec.Command("sh", "-c", "exit 1").Run()
+	require.Error(t, exitErr)
+
+	runner := mockRunnerForTest(ctrl)
+	gomock.InOrder(
+		runner.EXPECT().Output(gomock.Any(), gitCmdMatcher("rev-parse", "--verify", "--quiet", "v1.4.3^{commit}")).Return([]byte(fakeHeadOutput), nil),
+		runner.EXPECT().Output(gomock.Any(), gitCmdMatcher("rev-parse", "--verify", "--quiet", "nope^{commit}")).Return(nil, &Error{Err: exitErr}),
+	)
+	g := &DefaultGit{CmdRunner: runner}
+
+	commit, err := g.ResolveRef(t.Context(), "v1.4.3")
+	require.NoError(t, err)
+	assert.Equal(t, fakeHead, commit)
+
+	_, err = g.ResolveRef(t.Context(), "nope")
+	assert.ErrorIs(t, err, ErrUnknownRevision)
+	assert.EqualError(t, err, "resolving nope: unknown revision")
+}
+
 func TestLastMessage(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	expected := "minor: this should be bumping minor"
-	g := gitForTest(ctrl, withGitTagOutput(expected+"\n", "log", "-1", "--pretty=%B"))
+	g := gitForTest(ctrl, withGitTagOutput(expected+"\n", "log", "-1", "--pretty=%B", "HEAD^{commit}"))
 
-	commit, err := g.LastCommitMessage()
+	commit, err := g.LastCommitMessage(t.Context(), "HEAD")
 	require.NoError(t, err)
 
 	assert.Equal(t, expected, commit)
 }
 
-func TestTagged(t *testing.T) {
+func TestTagsPointingAt(t *testing.T) {
 	ctrl := gomock.NewController(t)
-	g := gitForTest(ctrl,
-		withGitTagOutput("true\n", "tag", "--contains", fakeHead),
-		withGitTagOutput(fakeHeadOutput, "rev-parse", "HEAD"),
+	runner := mockRunnerForTest(ctrl)
+	gomock.InOrder(
+		runner.EXPECT().Output(gomock.Any(), gitCmdMatcher("tag", "--points-at", "HEAD^{commit}")).Return([]byte("v1.4.3\nlatest\n"), nil),
+		runner.EXPECT().Output(gomock.Any(), gitCmdMatcher("tag", "--points-at", "HEAD~1^{commit}")).Return(nil, nil),
 	)
+	g := &DefaultGit{CmdRunner: runner}
 
-	commit, err := g.Tagged()
+	tags, err := g.TagsPointingAt(t.Context(), "HEAD")
 	require.NoError(t, err)
+	assert.Equal(t, []string{"v1.4.3", "latest"}, tags)
 
-	require.True(t, commit)
+	tags, err = g.TagsPointingAt(t.Context(), "HEAD~1")
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+}
+
+func TestTagsContaining(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := mockRunnerForTest(ctrl)
+	gomock.InOrder(
+		runner.EXPECT().Output(gomock.Any(), gitCmdMatcher("tag", "--contains", "v1.4.3^{commit}")).Return([]byte("v1.4.3\nv1.5.0\n"), nil),
+		runner.EXPECT().Output(gomock.Any(), gitCmdMatcher("tag", "--contains", "HEAD^{commit}")).Return(nil, nil),
+	)
+	g := &DefaultGit{CmdRunner: runner}
+
+	tags, err := g.TagsContaining(t.Context(), "v1.4.3")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1.4.3", "v1.5.0"}, tags)
+
+	tags, err = g.TagsContaining(t.Context(), "HEAD")
+	require.NoError(t, err)
+	assert.Empty(t, tags)
 }
 
 func TestTag(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	expected := "v10.10.10"
 	g := gitForTest(ctrl,
-		withGitTagOutput("", "tag", expected),
+		withGitTagOutput("", "tag", expected, "HEAD^{commit}"),
+		withGitTagOutput("", "tag", expected, "9d8ceaa^{commit}"),
+	)
+
+	require.NoError(t, g.Tag(t.Context(), expected, "HEAD"))
+	require.NoError(t, g.Tag(t.Context(), expected, "9d8ceaa"))
+}
+
+func TestTagAnnotated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	expected := "v10.10.10"
+	g := gitForTest(ctrl,
+		withGitTagOutput("", "tag", "-a", "-m", "release notes", expected, "HEAD^{commit}"),
+	)
+
+	require.NoError(t, g.Tag(t.Context(), expected, "HEAD", WithMessage("release notes")))
+}
+
+func TestCommitSubjects(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	g := gitForTest(ctrl,
+		withGitTagOutput("[minor] add feature\nfix typo\n", "log", "--format=%s", "v1.4.3^{commit}..release^{commit}"),
+		withGitTagOutput("", "log", "--format=%s", "HEAD^{commit}"),
+	)
+
+	subjects, err := g.CommitSubjects(t.Context(), "v1.4.3", "release")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"[minor] add feature", "fix typo"}, subjects)
+
+	subjects, err = g.CommitSubjects(t.Context(), "", "HEAD")
+	require.NoError(t, err)
+	assert.Empty(t, subjects)
+}
+
+func TestTagSigned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	expected := "v10.10.10"
+	g := gitForTest(ctrl,
+		withGitTagOutput("", "tag", "-s", "-m", expected, expected, "HEAD^{commit}"),
+		withGitTagOutput("", "-c", "gpg.format=ssh", "tag", "-u", "key.pub", "-m", "release notes", expected, "HEAD^{commit}"),
+	)
+
+	require.NoError(t, g.Tag(t.Context(), expected, "HEAD", WithSign("")))
+	require.NoError(t, g.Tag(t.Context(), expected, "HEAD", WithMessage("release notes"), WithSign("key.pub"), WithSignFormat("ssh")))
+}
+
+func TestVerifyTag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := mockRunnerForTest(ctrl)
+	gomock.InOrder(
+		runner.EXPECT().Run(gomock.Any(), gitCmdMatcher("tag", "-v", "v1.4.3")).Return(nil),
+		runner.EXPECT().Run(gomock.Any(), gitCmdMatcher("tag", "-v", "v1.2.1")).Return(fmt.Errorf("exit status 1")),
+	)
+	g := &DefaultGit{CmdRunner: runner}
+
+	require.NoError(t, g.VerifyTag(t.Context(), "v1.4.3"))
+	assert.EqualError(t, g.VerifyTag(t.Context(), "v1.2.1"), "verifying tag v1.2.1: exit status 1")
+}
+
+func TestDeleteTag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	g := gitForTest(ctrl, withGitTagOutput("Deleted tag 'v1.4.3' (was 0a1b2c3)\n", "tag", "-d", "v1.4.3"))
+
+	require.NoError(t, g.DeleteTag(t.Context(), "v1.4.3"))
+}
+
+func TestPush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := mockRunnerForTest(ctrl)
+	rejected := &Error{
+		Args:   []string{"push", "upstream", "refs/tags/v1.4.3"},
+		Stderr: " ! [rejected]        v1.4.3 -> v1.4.3 (already exists)\nerror: failed to push some refs\n",
+		Err:    &exec.ExitError{},
+	}
+	gomock.InOrder(
+		runner.EXPECT().Output(gomock.Any(), gitCmdMatcher("push", "origin", "refs/tags/v1.4.3")).Return(nil, nil),
+		runner.EXPECT().Output(gomock.Any(), gitCmdMatcher("push", "upstream", "refs/tags/v1.4.3")).Return(nil, rejected),
+		runner.EXPECT().Output(gomock.Any(), gitCmdMatcher("push", "origin", "refs/tags/v1.4.4")).Return(nil, fmt.Errorf("exit status 128")),
 	)
+	g := &DefaultGit{CmdRunner: runner}
+
+	require.NoError(t, g.Push(t.Context(), "origin", "v1.4.3"))
+
+	err := g.Push(t.Context(), "upstream", "v1.4.3")
+	assert.ErrorIs(t, err, ErrPushRejected)
+	assert.EqualError(t, err, "pushing tag v1.4.3 to upstream: ! [rejected]        v1.4.3 -> v1.4.3 (already exists); failed to push some refs")
 
-	require.NoError(t, g.Tag(expected))
+	err = g.Push(t.Context(), "origin", "v1.4.4")
+	assert.NotErrorIs(t, err, ErrPushRejected)
+	assert.EqualError(t, err, "pushing tag v1.4.4 to origin: exit status 128")
+}
+
+func TestFetchTags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := mockRunnerForTest(ctrl)
+	gomock.InOrder(
+		runner.EXPECT().Output(gomock.Any(), gitCmdMatcher("fetch", "--tags", "origin")).Return(nil, nil),
+		runner.EXPECT().Output(gomock.Any(), gitCmdMatcher("fetch", "--tags", "nope")).Return(nil, fmt.Errorf("exit status 128")),
+	)
+	g := &DefaultGit{CmdRunner: runner}
+
+	require.NoError(t, g.FetchTags(t.Context(), "origin"))
+	assert.EqualError(t, g.FetchTags(t.Context(), "nope"), "fetching tags from nope: exit status 128")
+}
+
+func TestIsShallow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := mockRunnerForTest(ctrl)
+	gomock.InOrder(
+		runner.EXPECT().Output(gomock.Any(), gitCmdMatcher("rev-parse", "--is-shallow-repository")).Return([]byte("true\n"), nil),
+		runner.EXPECT().Output(gomock.Any(), gitCmdMatcher("rev-parse", "--is-shallow-repository")).Return([]byte("false\n"), nil),
+	)
+	g := &DefaultGit{CmdRunner: runner}
+
+	shallow, err := g.IsShallow(t.Context())
+	require.NoError(t, err)
+	assert.True(t, shallow)
+
+	shallow, err = g.IsShallow(t.Context())
+	require.NoError(t, err)
+	assert.False(t, shallow)
+}
+
+func TestDeepen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := mockRunnerForTest(ctrl)
+	gomock.InOrder(
+		runner.EXPECT().Output(gomock.Any(), gitCmdMatcher("fetch", "--tags", "--deepen=50", "origin")).Return(nil, nil),
+		runner.EXPECT().Output(gomock.Any(), gitCmdMatcher("fetch", "--tags", "--unshallow", "origin")).Return(nil, nil),
+		runner.EXPECT().Output(gomock.Any(), gitCmdMatcher("fetch", "--tags", "--deepen=50", "nope")).Return(nil, fmt.Errorf("exit status 128")),
+	)
+	g := &DefaultGit{CmdRunner: runner}
+
+	require.NoError(t, g.Deepen(t.Context(), "origin", 50))
+	require.NoError(t, g.Deepen(t.Context(), "origin", 0))
+	assert.EqualError(t, g.Deepen(t.Context(), "nope", 50), "deepening the clone from nope: exit status 128")
+}
+
+func TestDeepenFetchesTags(t *testing.T) {
+	dir := repoForTest(t)
+	clone := filepath.Join(t.TempDir(), "clone")
+	out, err := exec.Command("git", "clone", "-q", "--depth", "1", "--no-tags", "file://"+dir, clone).CombinedOutput()
+	require.NoErrorf(t, err, "git clone: %s", out)
+	g := &DefaultGit{CmdRunner: &DefaultCmdRunner{}, Dir: clone}
+
+	// The tags of a clone without tags show up after deepening it
+	tags, err := g.Tags(t.Context(), "HEAD", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{""}, tags)
+
+	require.NoError(t, g.Deepen(t.Context(), "origin", 1))
+	tags, err = g.Tags(t.Context(), "HEAD", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nested/v2.0.0-rc.1", "v2.0.0-rc.1-alias"}, tags)
+
+	require.NoError(t, g.Deepen(t.Context(), "origin", 0))
+	tags, err = g.Tags(t.Context(), "HEAD", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nested/v2.0.0-rc.1", "v1.0.0", "v1.0.1", "v1.1.0", "v2.0.0-rc.1-alias"}, tags)
+	shallow, err := g.IsShallow(t.Context())
+	require.NoError(t, err)
+	assert.False(t, shallow)
+}
+
+func TestDir(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := mockRunnerForTest(ctrl)
+	runner.EXPECT().
+		Output(gomock.Any(), testutil.NewMatcherFunc("git tag in /src/repo", func(x interface{}) bool {
+			cmd := x.(*exec.Cmd)
+			return gitCmdMatcher("tag").Matches(cmd) && cmd.Dir == "/src/repo"
+		})).
+		Return([]byte("v1.0.1\n"), nil)
+	g := &DefaultGit{CmdRunner: runner, Dir: "/src/repo"}
+
+	tags, err := g.Tags(t.Context(), "HEAD", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1.0.1"}, tags)
+}
+
+func TestCmdRunnerContext(t *testing.T) {
+	runner := &DefaultCmdRunner{}
+	g := &DefaultGit{CmdRunner: runner}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err := g.Tags(ctx, "HEAD", false)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// A hung command is killed when the context times out
+	ctx, cancel = context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = runner.Output(ctx, exec.CommandContext(ctx, "sleep", "10"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.ErrorIs(t, runner.Run(ctx, exec.CommandContext(ctx, "sleep", "10")), context.DeadlineExceeded)
+}
+
+func TestRefsNamedLikeFiles(t *testing.T) {
+	repoForTest(t)
+	for _, name := range []string{"maintenance", "v1.0.0"} {
+		require.NoError(t, os.WriteFile(name, nil, 0o644))
+	}
+
+	for _, g := range []Git{&DefaultGit{CmdRunner: &DefaultCmdRunner{}}, &NativeGit{}} {
+		message, err := g.LastCommitMessage(t.Context(), "maintenance")
+		require.NoError(t, err)
+		assert.Equal(t, "fix", strings.SplitN(message, "\n", 2)[0])
+
+		subjects, err := g.CommitSubjects(t.Context(), "v1.0.0", "maintenance")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"fix"}, subjects)
+	}
 }
@@ -5,6 +5,7 @@
 package git
 
 import (
+	context "context"
 	exec "os/exec"
 	reflect "reflect"
 
@@ -35,32 +36,32 @@ func (m *MockCmdRunner) EXPECT() *MockCmdRunnerMockRecorder {
 }
 
 // Output mocks base method.
-func (m *MockCmdRunner) Output(arg0 *exec.Cmd) ([]byte, error) {
+func (m *MockCmdRunner) Output(arg0 context.Context, arg1 *exec.Cmd) ([]byte, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Output", arg0)
+	ret := m.ctrl.Call(m, "Output", arg0, arg1)
 	ret0, _ := ret[0].([]byte)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Output indicates an expected call of Output.
-func (mr *MockCmdRunnerMockRecorder) Output(arg0 interface{}) *gomock.Call {
+func (mr *MockCmdRunnerMockRecorder) Output(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Output", reflect.TypeOf((*MockCmdRunner)(nil).Output), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Output", reflect.TypeOf((*MockCmdRunner)(nil).Output), arg0, arg1)
 }
 
 // Run mocks base method.
-func (m *MockCmdRunner) Run(arg0 *exec.Cmd) error {
+func (m *MockCmdRunner) Run(arg0 context.Context, arg1 *exec.Cmd) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Run", arg0)
+	ret := m.ctrl.Call(m, "Run", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Run indicates an expected call of Run.
-func (mr *MockCmdRunnerMockRecorder) Run(arg0 interface{}) *gomock.Call {
+func (mr *MockCmdRunnerMockRecorder) Run(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockCmdRunner)(nil).Run), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockCmdRunner)(nil).Run), arg0, arg1)
 }
 
 // MockGit is a mock of Git interface.
@@ -86,76 +87,211 @@ func (m *MockGit) EXPECT() *MockGitMockRecorder {
 	return m.recorder
 }
 
+// CommitSubjects mocks base method.
+func (m *MockGit) CommitSubjects(ctx context.Context, since, ref string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommitSubjects", ctx, since, ref)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CommitSubjects indicates an expected call of CommitSubjects.
+func (mr *MockGitMockRecorder) CommitSubjects(ctx, since, ref interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitSubjects", reflect.TypeOf((*MockGit)(nil).CommitSubjects), ctx, since, ref)
+}
+
+// Deepen mocks base method.
+func (m *MockGit) Deepen(ctx context.Context, remote string, depth int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deepen", ctx, remote, depth)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Deepen indicates an expected call of Deepen.
+func (mr *MockGitMockRecorder) Deepen(ctx, remote, depth interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deepen", reflect.TypeOf((*MockGit)(nil).Deepen), ctx, remote, depth)
+}
+
+// DeleteTag mocks base method.
+func (m *MockGit) DeleteTag(ctx context.Context, tag string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTag", ctx, tag)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTag indicates an expected call of DeleteTag.
+func (mr *MockGitMockRecorder) DeleteTag(ctx, tag interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTag", reflect.TypeOf((*MockGit)(nil).DeleteTag), ctx, tag)
+}
+
+// FetchTags mocks base method.
+func (m *MockGit) FetchTags(ctx context.Context, remote string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchTags", ctx, remote)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FetchTags indicates an expected call of FetchTags.
+func (mr *MockGitMockRecorder) FetchTags(ctx, remote interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchTags", reflect.TypeOf((*MockGit)(nil).FetchTags), ctx, remote)
+}
+
+// IsShallow mocks base method.
+func (m *MockGit) IsShallow(ctx context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsShallow", ctx)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsShallow indicates an expected call of IsShallow.
+func (mr *MockGitMockRecorder) IsShallow(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsShallow", reflect.TypeOf((*MockGit)(nil).IsShallow), ctx)
+}
+
 // LastCommit mocks base method.
-func (m *MockGit) LastCommit(short bool) (string, error) {
+func (m *MockGit) LastCommit(ctx context.Context, ref string, short bool) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "LastCommit", short)
+	ret := m.ctrl.Call(m, "LastCommit", ctx, ref, short)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // LastCommit indicates an expected call of LastCommit.
-func (mr *MockGitMockRecorder) LastCommit(short interface{}) *gomock.Call {
+func (mr *MockGitMockRecorder) LastCommit(ctx, ref, short interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastCommit", reflect.TypeOf((*MockGit)(nil).LastCommit), short)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastCommit", reflect.TypeOf((*MockGit)(nil).LastCommit), ctx, ref, short)
 }
 
 // LastCommitMessage mocks base method.
-func (m *MockGit) LastCommitMessage() (string, error) {
+func (m *MockGit) LastCommitMessage(ctx context.Context, ref string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "LastCommitMessage")
+	ret := m.ctrl.Call(m, "LastCommitMessage", ctx, ref)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // LastCommitMessage indicates an expected call of LastCommitMessage.
-func (mr *MockGitMockRecorder) LastCommitMessage() *gomock.Call {
+func (mr *MockGitMockRecorder) LastCommitMessage(ctx, ref interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastCommitMessage", reflect.TypeOf((*MockGit)(nil).LastCommitMessage))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastCommitMessage", reflect.TypeOf((*MockGit)(nil).LastCommitMessage), ctx, ref)
 }
 
-// Tag mocks base method.
-func (m *MockGit) Tag(tag string) error {
+// Push mocks base method.
+func (m *MockGit) Push(ctx context.Context, remote, tag string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Tag", tag)
+	ret := m.ctrl.Call(m, "Push", ctx, remote, tag)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Tag indicates an expected call of Tag.
-func (mr *MockGitMockRecorder) Tag(tag interface{}) *gomock.Call {
+// Push indicates an expected call of Push.
+func (mr *MockGitMockRecorder) Push(ctx, remote, tag interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tag", reflect.TypeOf((*MockGit)(nil).Tag), tag)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Push", reflect.TypeOf((*MockGit)(nil).Push), ctx, remote, tag)
 }
 
-// Tagged mocks base method.
-func (m *MockGit) Tagged() (bool, error) {
+// ResolveRef mocks base method.
+func (m *MockGit) ResolveRef(ctx context.Context, ref string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Tagged")
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "ResolveRef", ctx, ref)
+	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Tagged indicates an expected call of Tagged.
-func (mr *MockGitMockRecorder) Tagged() *gomock.Call {
+// ResolveRef indicates an expected call of ResolveRef.
+func (mr *MockGitMockRecorder) ResolveRef(ctx, ref interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tagged", reflect.TypeOf((*MockGit)(nil).Tagged))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveRef", reflect.TypeOf((*MockGit)(nil).ResolveRef), ctx, ref)
+}
+
+// Tag mocks base method.
+func (m *MockGit) Tag(ctx context.Context, tag, ref string, options ...TagOption) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, tag, ref}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Tag", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Tag indicates an expected call of Tag.
+func (mr *MockGitMockRecorder) Tag(ctx, tag, ref interface{}, options ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, tag, ref}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tag", reflect.TypeOf((*MockGit)(nil).Tag), varargs...)
 }
 
 // Tags mocks base method.
-func (m *MockGit) Tags(merged bool) ([]string, error) {
+func (m *MockGit) Tags(ctx context.Context, ref string, merged bool) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Tags", merged)
+	ret := m.ctrl.Call(m, "Tags", ctx, ref, merged)
 	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Tags indicates an expected call of Tags.
-func (mr *MockGitMockRecorder) Tags(merged interface{}) *gomock.Call {
+func (mr *MockGitMockRecorder) Tags(ctx, ref, merged interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tags", reflect.TypeOf((*MockGit)(nil).Tags), ctx, ref, merged)
+}
+
+// TagsContaining mocks base method.
+func (m *MockGit) TagsContaining(ctx context.Context, ref string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TagsContaining", ctx, ref)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TagsContaining indicates an expected call of TagsContaining.
+func (mr *MockGitMockRecorder) TagsContaining(ctx, ref interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TagsContaining", reflect.TypeOf((*MockGit)(nil).TagsContaining), ctx, ref)
+}
+
+// TagsPointingAt mocks base method.
+func (m *MockGit) TagsPointingAt(ctx context.Context, ref string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TagsPointingAt", ctx, ref)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TagsPointingAt indicates an expected call of TagsPointingAt.
+func (mr *MockGitMockRecorder) TagsPointingAt(ctx, ref interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TagsPointingAt", reflect.TypeOf((*MockGit)(nil).TagsPointingAt), ctx, ref)
+}
+
+// VerifyTag mocks base method.
+func (m *MockGit) VerifyTag(ctx context.Context, tag string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyTag", ctx, tag)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyTag indicates an expected call of VerifyTag.
+func (mr *MockGitMockRecorder) VerifyTag(ctx, tag interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tags", reflect.TypeOf((*MockGit)(nil).Tags), merged)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyTag", reflect.TypeOf((*MockGit)(nil).VerifyTag), ctx, tag)
 }
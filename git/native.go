@@ -0,0 +1,432 @@
+package git
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// NativeGit implements Git by reading refs and objects directly from the
+// .git directory of the repository, without running git. Tags are created as
+// lightweight tags.
+type NativeGit struct {
+	// Dir is the directory of the repository (or any directory inside it),
+	// the working directory when empty
+	Dir string
+
+	once sync.Once
+	repo *repository
+	err  error
+}
+
+var (
+	_ Git = &NativeGit{}
+
+	// ErrUnsupported is returned for operations the native backend can't do
+	ErrUnsupported = errors.New("not supported by the native git backend")
+)
+
+// shortSHALength is the minimum length of abbreviated SHAs, like git
+const shortSHALength = 7
+
+// open finds the repository on first use. Reading files can't be
+// cancelled, so the context is only checked before.
+func (g *NativeGit) open(ctx context.Context) (*repository, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	g.once.Do(func() {
+		dir := g.Dir
+		if dir == "" {
+			dir = "."
+		}
+		g.repo, g.err = openRepository(dir)
+	})
+	return g.repo, g.err
+}
+
+// ProvideNativeGit returns a NativeGit for the repository at dir, and a
+// cleanup function closing it
+func ProvideNativeGit(dir string) (*NativeGit, func()) {
+	g := &NativeGit{Dir: dir}
+	return g, func() {
+		_ = g.Close()
+	}
+}
+
+// Close closes the files kept open to read the repository. The repository
+// is opened again if it is used after.
+func (g *NativeGit) Close() error {
+	if g.repo == nil {
+		return nil
+	}
+	return g.repo.close()
+}
+
+// commit returns the repository and the SHA of the commit ref points at
+func (g *NativeGit) commit(ctx context.Context, ref string) (*repository, string, error) {
+	r, err := g.open(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	sha, err := g.resolve(r, ref)
+	return r, sha, err
+}
+
+// Tags returns the list of git tags as a string slice; only the tags merged
+// into ref when merged is set
+func (g *NativeGit) Tags(ctx context.Context, ref string, merged bool) ([]string, error) {
+	r, err := g.open(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching git tags: %w", err)
+	}
+	refs, err := r.listRefs("refs/tags/")
+	if err != nil {
+		return nil, fmt.Errorf("fetching git tags: %w", err)
+	}
+
+	var reachable map[string]bool
+	if merged {
+		commit, err := g.resolve(r, ref)
+		if err != nil {
+			return nil, fmt.Errorf("fetching git tags: %w", err)
+		}
+		reachable = map[string]bool{}
+		err = r.ancestors(commit, func(sha string) bool {
+			reachable[sha] = true
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("fetching git tags: %w", err)
+		}
+	}
+
+	tags := make([]string, 0, len(refs))
+	for _, name := range refs {
+		if merged {
+			commit, err := r.peelRef(name)
+			if err != nil || !reachable[commit] {
+				continue
+			}
+		}
+		tags = append(tags, strings.TrimPrefix(name, "refs/tags/"))
+	}
+	return tags, nil
+}
+
+// Tag creates a lightweight tag of the commit ref points at; annotated and
+// signed tags are not supported
+func (g *NativeGit) Tag(ctx context.Context, tag, ref string, options ...TagOption) error {
+	if opts := NewTagOptions(options...); opts.Message != "" || opts.Sign {
+		return fmt.Errorf("tagging the commit in git: annotated and signed tags are %w", ErrUnsupported)
+	}
+	r, commit, err := g.commit(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("tagging the commit in git: %w", err)
+	}
+	if !validTagName(tag) {
+		return fmt.Errorf("tagging the commit in git: '%s' is not a valid tag name", tag)
+	}
+	name := "refs/tags/" + tag
+	if _, found, err := r.readRef(name); err != nil {
+		return fmt.Errorf("tagging the commit in git: %w", err)
+	} else if found {
+		return fmt.Errorf("tagging the commit in git: %s: %w", tag, ErrTagExists)
+	}
+
+	path := r.refPath(name)
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("tagging the commit in git: %w", err)
+	}
+	if err = writeFileLocked(path, commit+"\n"); err != nil {
+		return fmt.Errorf("tagging the commit in git: %w", err)
+	}
+	return nil
+}
+
+// DeleteTag deletes a local tag, loose or packed
+func (g *NativeGit) DeleteTag(ctx context.Context, tag string) error {
+	r, err := g.open(ctx)
+	if err != nil {
+		return fmt.Errorf("deleting tag %s: %w", tag, err)
+	}
+	ref := "refs/tags/" + tag
+	if _, found, err := r.readRef(ref); err != nil {
+		return fmt.Errorf("deleting tag %s: %w", tag, err)
+	} else if !found {
+		return fmt.Errorf("deleting tag %s: tag '%s' not found", tag, tag)
+	}
+
+	if err = os.Remove(r.refPath(ref)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("deleting tag %s: %w", tag, err)
+	}
+	if err = r.removePackedRef(ref); err != nil {
+		return fmt.Errorf("deleting tag %s: %w", tag, err)
+	}
+	return nil
+}
+
+// Push is not supported by the native backend
+func (g *NativeGit) Push(ctx context.Context, remote, tag string) error {
+	return fmt.Errorf("pushing tag %s to %s: %w", tag, remote, ErrUnsupported)
+}
+
+// FetchTags is not supported by the native backend
+func (g *NativeGit) FetchTags(ctx context.Context, remote string) error {
+	return fmt.Errorf("fetching tags from %s: %w", remote, ErrUnsupported)
+}
+
+// IsShallow returns true if the repository is a shallow clone, whose history
+// is cut at some commits
+func (g *NativeGit) IsShallow(ctx context.Context) (bool, error) {
+	r, err := g.open(ctx)
+	if err != nil {
+		return false, fmt.Errorf("checking for a shallow clone: %w", err)
+	}
+	return len(r.shallow) > 0, nil
+}
+
+// Deepen is not supported by the native backend
+func (g *NativeGit) Deepen(ctx context.Context, remote string, depth int) error {
+	return fmt.Errorf("deepening the clone from %s: %w", remote, ErrUnsupported)
+}
+
+// VerifyTag is not supported by the native backend
+func (g *NativeGit) VerifyTag(ctx context.Context, tag string) error {
+	return fmt.Errorf("verifying tag %s: %w", tag, ErrUnsupported)
+}
+
+// LastCommit gets the SHA of the commit ref points at
+func (g *NativeGit) LastCommit(ctx context.Context, ref string, short bool) (string, error) {
+	r, commit, err := g.commit(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("fetching git commit: %w", err)
+	}
+	if short {
+		if commit, err = r.abbreviate(commit, shortSHALength); err != nil {
+			return "", fmt.Errorf("fetching git commit: %w", err)
+		}
+	}
+	return commit, nil
+}
+
+// ResolveRef returns the SHA of the commit a ref (e.g. a tag) points at
+func (g *NativeGit) ResolveRef(ctx context.Context, ref string) (string, error) {
+	r, err := g.open(ctx)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	commit, err := g.resolve(r, ref)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	return commit, nil
+}
+
+// CommitSubjects returns the subjects of the commits since a ref (e.g. the
+// previous tag) up to ref, newest first; all commits up to ref when since is
+// empty
+func (g *NativeGit) CommitSubjects(ctx context.Context, since, ref string) ([]string, error) {
+	r, head, err := g.commit(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("fetching commit subjects: %w", err)
+	}
+
+	excluded := map[string]bool{}
+	if since != "" {
+		commit, err := g.resolve(r, since)
+		if err != nil {
+			return nil, fmt.Errorf("fetching commit subjects: %w", err)
+		}
+		err = r.ancestors(commit, func(sha string) bool {
+			excluded[sha] = true
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("fetching commit subjects: %w", err)
+		}
+	}
+
+	// Walk the commits that are not excluded, like git log since..ref
+	var commits []*commit
+	seen := map[string]bool{head: true}
+	queue := []string{head}
+	for len(queue) > 0 {
+		var sha string
+		sha, queue = queue[0], queue[1:]
+		if excluded[sha] {
+			continue
+		}
+		c, err := r.readCommit(sha)
+		if err != nil {
+			return nil, fmt.Errorf("fetching commit subjects: %w", err)
+		}
+		commits = append(commits, c)
+		for _, parent := range c.parents {
+			if !seen[parent] {
+				seen[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+
+	slices.SortStableFunc(commits, func(a, b *commit) int {
+		return cmp.Compare(b.time, a.time)
+	})
+	var subjects []string
+	for _, c := range commits {
+		subjects = append(subjects, c.subject())
+	}
+	return subjects, nil
+}
+
+// LastCommitMessage gets the message of the commit ref points at
+func (g *NativeGit) LastCommitMessage(ctx context.Context, ref string) (string, error) {
+	r, sha, err := g.commit(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("fetching git commit message: %w", err)
+	}
+	c, err := r.readCommit(sha)
+	if err != nil {
+		return "", fmt.Errorf("fetching git commit message: %w", err)
+	}
+	return strings.TrimSpace(c.message), nil
+}
+
+// TagsPointingAt returns the tags of the commit ref points at, including
+// tags of its tags
+func (g *NativeGit) TagsPointingAt(ctx context.Context, ref string) ([]string, error) {
+	r, head, err := g.commit(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("fetching tags of %s: %w", ref, err)
+	}
+	refs, err := r.listRefs("refs/tags/")
+	if err != nil {
+		return nil, fmt.Errorf("fetching tags of %s: %w", ref, err)
+	}
+
+	var tags []string
+	for _, name := range refs {
+		// Tags of trees and blobs don't point at a commit
+		if commit, err := r.peelRef(name); err == nil && commit == head {
+			tags = append(tags, strings.TrimPrefix(name, "refs/tags/"))
+		}
+	}
+	return tags, nil
+}
+
+// TagsContaining returns the tags of the commit ref points at and of its
+// descendants
+func (g *NativeGit) TagsContaining(ctx context.Context, ref string) ([]string, error) {
+	r, target, err := g.commit(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("fetching tags containing %s: %w", ref, err)
+	}
+	refs, err := r.listRefs("refs/tags/")
+	if err != nil {
+		return nil, fmt.Errorf("fetching tags containing %s: %w", ref, err)
+	}
+
+	commits := map[string]string{}
+	for _, name := range refs {
+		if commit, err := r.peelRef(name); err == nil {
+			commits[name] = commit
+		}
+	}
+	contains, err := r.containing(target, slices.Collect(maps.Values(commits)))
+	if err != nil {
+		return nil, fmt.Errorf("fetching tags containing %s: %w", ref, err)
+	}
+
+	var tags []string
+	for _, name := range refs {
+		if commit, ok := commits[name]; ok && contains[commit] {
+			tags = append(tags, strings.TrimPrefix(name, "refs/tags/"))
+		}
+	}
+	return tags, nil
+}
+
+// resolve returns the commit a revision points at, following ancestry
+// suffixes such as HEAD~2, HEAD^2 or v1.0.0^{commit}
+func (g *NativeGit) resolve(r *repository, rev string) (string, error) {
+	name, suffix := rev, ""
+	if i := strings.IndexAny(rev, "~^"); i > 0 {
+		name, suffix = rev[:i], rev[i:]
+	}
+	sha, err := r.resolve(name)
+	if err != nil {
+		return "", err
+	}
+	if sha, err = r.peel(sha); err != nil {
+		return "", err
+	}
+
+	for suffix != "" {
+		op := suffix[0]
+		suffix = suffix[1:]
+		if rest, ok := strings.CutPrefix(suffix, "{commit}"); ok && op == '^' {
+			suffix = rest
+			continue
+		}
+		n := 1
+		if digits := len(suffix) - len(strings.TrimLeft(suffix, "0123456789")); digits > 0 {
+			if n, err = strconv.Atoi(suffix[:digits]); err != nil {
+				return "", fmt.Errorf("%w %s", ErrUnknownRevision, rev)
+			}
+			suffix = suffix[digits:]
+		}
+		if op == '^' && n == 0 {
+			continue
+		}
+
+		// ~n follows n first parents, ^n selects the nth parent
+		steps, parent := n, 0
+		if op == '^' {
+			steps, parent = 1, n-1
+		} else if op != '~' {
+			return "", fmt.Errorf("%w %s", ErrUnknownRevision, rev)
+		}
+		for range steps {
+			c, err := r.readCommit(sha)
+			if err != nil {
+				return "", err
+			}
+			if parent >= len(c.parents) {
+				return "", fmt.Errorf("%w %s", ErrUnknownRevision, rev)
+			}
+			sha = c.parents[parent]
+		}
+	}
+	return sha, nil
+}
+
+// validTagName reports whether a tag name is a valid ref name, see
+// https://git-scm.com/docs/git-check-ref-format
+func validTagName(tag string) bool {
+	if tag == "" || tag == "@" || strings.HasPrefix(tag, "-") || strings.HasSuffix(tag, "/") ||
+		strings.HasSuffix(tag, ".") || strings.Contains(tag, "..") || strings.Contains(tag, "@{") ||
+		strings.Contains(tag, "//") || strings.ContainsAny(tag, " ~^:?*[\\\x7f") {
+		return false
+	}
+	for _, c := range tag {
+		if c < 0x20 {
+			return false
+		}
+	}
+	for _, component := range strings.Split(tag, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return false
+		}
+	}
+	return true
+}
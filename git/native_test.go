@@ -0,0 +1,453 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// repoForTest creates a repository with a branch, lightweight and annotated
+// tags and a large file changed by every commit (so packing creates
+// deltas), and changes into it. The native backend is compared with the git
+// command, so tests are skipped when git is not installed.
+func repoForTest(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	// Commits get distinct dates so that they are ordered the same way
+	date := 1700000000
+	run := func(args ...string) {
+		t.Helper()
+		date += 60
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+			fmt.Sprintf("GIT_AUTHOR_DATE=@%d +0000", date),
+			fmt.Sprintf("GIT_COMMITTER_DATE=@%d +0000", date),
+			"GIT_CONFIG_NOSYSTEM=1", "HOME="+dir,
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoErrorf(t, err, "git %v: %s", args, out)
+	}
+	content := strings.Repeat("A line of a file that is changed by every commit.\n", 200)
+	commit := func(message string) {
+		t.Helper()
+		content += message + "\n"
+		require.NoError(t, os.WriteFile("file.txt", []byte(content), 0o644))
+		run("add", "file.txt")
+		run("commit", "-q", "-m", message+"\n\n"+strings.Repeat("A long body. ", 200))
+	}
+
+	run("init", "-q", "-b", "main")
+	commit("initial")
+	run("tag", "v1.0.0")
+	commit("[minor] second")
+	run("tag", "-a", "-m", "release", "v1.1.0")
+	run("checkout", "-q", "-b", "maintenance", "v1.0.0")
+	commit("fix")
+	run("tag", "v1.0.1")
+	run("checkout", "-q", "main")
+	run("merge", "-q", "--no-ff", "-s", "ours", "-m", "Merge branch 'maintenance'\nwith a second line", "maintenance")
+	commit("[major] third")
+	run("tag", "-a", "-m", "candidate", "nested/v2.0.0-rc.1")
+	run("tag", "-a", "-m", "tag of a tag", "v2.0.0-rc.1-alias", "nested/v2.0.0-rc.1")
+	return dir
+}
+
+func packForTest(t *testing.T) {
+	t.Helper()
+	out, err := exec.Command("git", "gc", "-q", "--aggressive").CombinedOutput()
+	require.NoErrorf(t, err, "git gc: %s", out)
+	refs, err := filepath.Glob(filepath.Join(".git", "refs", "tags", "*"))
+	require.NoError(t, err)
+	require.Empty(t, refs, "tags should be packed")
+
+	// Entries of deltified objects have seven fields or more
+	packs, err := filepath.Glob(filepath.Join(".git", "objects", "pack", "*.idx"))
+	require.NoError(t, err)
+	out, err = exec.Command("git", append([]string{"verify-pack", "-v"}, packs...)...).Output()
+	require.NoError(t, err)
+	deltas := 0
+	for _, line := range strings.Split(string(out), "\n") {
+		if len(strings.Fields(line)) >= 7 {
+			deltas++
+		}
+	}
+	require.NotZero(t, deltas, "objects should be deltified")
+}
+
+func assertSameAsGit(t *testing.T) {
+	t.Helper()
+	expected := &DefaultGit{CmdRunner: &DefaultCmdRunner{}}
+	actual := &NativeGit{}
+	defer actual.Close()
+
+	// HEAD, a branch, an annotated tag, a tag of a tag, ancestry suffixes
+	// and abbreviated SHAs
+	refs := []string{"HEAD", "maintenance", "v1.1.0", "v2.0.0-rc.1-alias", "HEAD~2", "HEAD~1^2", "v1.1.0^{commit}~1", "HEAD^0"}
+	for _, rev := range []string{"v1.0.1", "v1.0.1~1"} {
+		out, err := exec.Command("git", "rev-parse", "--short", rev).Output()
+		require.NoError(t, err)
+		refs = append(refs, strings.TrimSpace(string(out)))
+	}
+
+	for _, ref := range refs {
+		for _, merged := range []bool{false, true} {
+			expectedTags, err := expected.Tags(t.Context(), ref, merged)
+			require.NoError(t, err)
+			actualTags, err := actual.Tags(t.Context(), ref, merged)
+			require.NoError(t, err)
+			assert.Equalf(t, expectedTags, actualTags, "Tags(%q, %v)", ref, merged)
+
+			for _, tag := range expectedTags {
+				expectedCommit, err := expected.ResolveRef(t.Context(), tag)
+				require.NoError(t, err)
+				actualCommit, err := actual.ResolveRef(t.Context(), tag)
+				require.NoError(t, err)
+				assert.Equalf(t, expectedCommit, actualCommit, "ResolveRef(%q)", tag)
+			}
+		}
+
+		for _, short := range []bool{false, true} {
+			expectedCommit, err := expected.LastCommit(t.Context(), ref, short)
+			require.NoError(t, err)
+			actualCommit, err := actual.LastCommit(t.Context(), ref, short)
+			require.NoError(t, err)
+			assert.Equalf(t, expectedCommit, actualCommit, "LastCommit(%q, %v)", ref, short)
+		}
+
+		expectedMessage, err := expected.LastCommitMessage(t.Context(), ref)
+		require.NoError(t, err)
+		actualMessage, err := actual.LastCommitMessage(t.Context(), ref)
+		require.NoError(t, err)
+		assert.Equalf(t, expectedMessage, actualMessage, "LastCommitMessage(%q)", ref)
+
+		// git before 2.42 doesn't peel tags of tags for --points-at, so the
+		// tags are compared without them
+		expectedTags, err := expected.TagsPointingAt(t.Context(), ref)
+		require.NoError(t, err)
+		actualTags, err := actual.TagsPointingAt(t.Context(), ref)
+		require.NoError(t, err)
+		assert.Equalf(t, expectedTags, slices.DeleteFunc(actualTags, func(tag string) bool {
+			return tag == "v2.0.0-rc.1-alias" && !slices.Contains(expectedTags, tag)
+		}), "TagsPointingAt(%q)", ref)
+
+		expectedTags, err = expected.TagsContaining(t.Context(), ref)
+		require.NoError(t, err)
+		actualTags, err = actual.TagsContaining(t.Context(), ref)
+		require.NoError(t, err)
+		assert.Equalf(t, expectedTags, actualTags, "TagsContaining(%q)", ref)
+
+		for _, since := range []string{"", "v1.0.0", "v1.1.0", "HEAD"} {
+			expectedSubjects, err := expected.CommitSubjects(t.Context(), since, ref)
+			require.NoError(t, err)
+			actualSubjects, err := actual.CommitSubjects(t.Context(), since, ref)
+			require.NoError(t, err)
+			assert.Equalf(t, expectedSubjects, actualSubjects, "CommitSubjects(%q, %q)", since, ref)
+		}
+	}
+}
+
+// assertPacksClosed checks that no packfile is open, where the open files
+// can be listed
+func assertPacksClosed(t *testing.T) {
+	t.Helper()
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return
+	}
+	for _, fd := range fds {
+		target, _ := os.Readlink(filepath.Join("/proc/self/fd", fd.Name()))
+		assert.NotContainsf(t, target, ".pack", "open file %s", target)
+	}
+}
+
+func TestNativeGitLooseObjects(t *testing.T) {
+	repoForTest(t)
+	assertSameAsGit(t)
+}
+
+func TestNativeGitPackedObjects(t *testing.T) {
+	repoForTest(t)
+	packForTest(t)
+	assertSameAsGit(t)
+}
+
+func TestNativeGitReadObject(t *testing.T) {
+	repoForTest(t)
+	packForTest(t)
+	r, err := openRepository(".")
+	require.NoError(t, err)
+
+	out, err := exec.Command("git", "rev-list", "--objects", "--all").Output()
+	require.NoError(t, err)
+	objects := strings.Split(strings.TrimSpace(string(out)), "\n")
+	out, err = exec.Command("git", "for-each-ref", "--format=%(objectname)", "refs/tags").Output()
+	require.NoError(t, err)
+	objects = append(objects, strings.Fields(string(out))...)
+
+	types := map[int]string{objCommit: "commit", objTree: "tree", objBlob: "blob", objTag: "tag"}
+	for _, object := range objects {
+		sha, _, _ := strings.Cut(object, " ")
+		typ, data, err := r.readObject(sha)
+		require.NoErrorf(t, err, "readObject(%s)", sha)
+
+		expected, err := exec.Command("git", "cat-file", types[typ], sha).Output()
+		require.NoErrorf(t, err, "git cat-file %s %s", types[typ], sha)
+		assert.Equalf(t, expected, data, "readObject(%s)", sha)
+	}
+
+	require.NoError(t, r.close())
+}
+
+func TestNativeGitPackedRefs(t *testing.T) {
+	repoForTest(t)
+	out, err := exec.Command("git", "tag", "tree", "HEAD^{tree}").CombinedOutput()
+	require.NoErrorf(t, err, "git tag: %s", out)
+	packForTest(t)
+	g := &NativeGit{}
+	defer g.Close()
+	r, err := g.open(t.Context())
+	require.NoError(t, err)
+
+	// Tags are peeled with the packed-refs file, like ref^{}
+	names, err := r.listRefs("refs/tags/")
+	require.NoError(t, err)
+	for _, name := range names {
+		expected, err := exec.Command("git", "rev-parse", name+"^{}").Output()
+		require.NoError(t, err)
+		actual, err := r.peelRef(name)
+		require.NoError(t, err)
+		assert.Equalf(t, strings.TrimSpace(string(expected)), actual, "peelRef(%s)", name)
+	}
+	assert.NotEmpty(t, r.packed["refs/tags/v1.1.0"].peeled)
+
+	// The tag of a tree neither points at nor contains a commit
+	tags, err := g.TagsContaining(t.Context(), "v1.0.0")
+	require.NoError(t, err)
+	assert.NotContains(t, tags, "tree")
+	tags, err = g.TagsPointingAt(t.Context(), "HEAD")
+	require.NoError(t, err)
+	assert.NotContains(t, tags, "tree")
+
+	// A deleted tag is gone from the file read before
+	require.NoError(t, g.DeleteTag(t.Context(), "v1.0.0"))
+	_, err = g.ResolveRef(t.Context(), "v1.0.0")
+	assert.ErrorIs(t, err, ErrUnknownRevision)
+}
+
+func TestNativeGitClose(t *testing.T) {
+	repoForTest(t)
+	packForTest(t)
+	g := &NativeGit{}
+	require.NoError(t, g.Close())
+
+	_, err := g.Tags(t.Context(), "HEAD", true)
+	require.NoError(t, err)
+	require.NoError(t, g.Close())
+	assertPacksClosed(t)
+
+	// The packfiles are opened again when needed
+	_, err = g.Tags(t.Context(), "HEAD", true)
+	require.NoError(t, err)
+	require.NoError(t, g.Close())
+}
+
+func TestNativeGitAbbreviate(t *testing.T) {
+	repoForTest(t)
+
+	// Two blobs whose SHAs share the first seven characters
+	seen := map[string]string{}
+	var contents [2]string
+	for i := 0; contents[0] == ""; i++ {
+		content := strconv.Itoa(i)
+		sha := fmt.Sprintf("%x", sha1.Sum(fmt.Appendf(nil, "blob %d\x00%s", len(content), content)))
+		if other, ok := seen[sha[:shortSHALength]]; ok {
+			contents = [2]string{other, content}
+		}
+		seen[sha[:shortSHALength]] = content
+	}
+	var shas []string
+	for _, content := range contents {
+		cmd := exec.Command("git", "hash-object", "-w", "--stdin")
+		cmd.Stdin = strings.NewReader(content)
+		out, err := cmd.Output()
+		require.NoError(t, err)
+		shas = append(shas, strings.TrimSpace(string(out)))
+	}
+
+	r, err := openRepository(".")
+	require.NoError(t, err)
+	defer r.close()
+	for _, sha := range shas {
+		expected, err := exec.Command("git", "rev-parse", "--short", sha).Output()
+		require.NoError(t, err)
+		actual, err := r.abbreviate(sha, shortSHALength)
+		require.NoError(t, err)
+		assert.Greater(t, len(actual), shortSHALength)
+		assert.Equalf(t, strings.TrimSpace(string(expected)), actual, "abbreviate(%s)", sha)
+	}
+}
+
+func TestNativeGitCorruptSizes(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, err := zw.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	data, err := inflate(bytes.NewReader(compressed.Bytes()), 5)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), data)
+	_, err = inflate(bytes.NewReader(compressed.Bytes()), 1<<62)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// A base of 5 bytes, a result of 1<<62 bytes, and a copy of the base
+	_, err = applyDelta([]byte("hello"), []byte{0x05, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x40, 0x90, 0x05})
+	assert.ErrorIs(t, err, errCorruptPack)
+	// A result of 2 bytes, and an insert of 3
+	_, err = applyDelta([]byte("hello"), []byte{0x05, 0x02, 0x03, 'a', 'b', 'c'})
+	assert.ErrorIs(t, err, errCorruptPack)
+	data, err = applyDelta([]byte("hello"), []byte{0x05, 0x03, 0x90, 0x03})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hel"), data)
+}
+
+func TestNativeGitTag(t *testing.T) {
+	repoForTest(t)
+	packForTest(t)
+	g := &NativeGit{}
+	defer g.Close()
+
+	require.NoError(t, g.Tag(t.Context(), "v2.0.0", "HEAD"))
+	tags, err := g.TagsPointingAt(t.Context(), "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nested/v2.0.0-rc.1", "v2.0.0", "v2.0.0-rc.1-alias"}, tags)
+
+	head, err := g.LastCommit(t.Context(), "HEAD", false)
+	require.NoError(t, err)
+	out, err := exec.Command("git", "rev-parse", "v2.0.0").Output()
+	require.NoError(t, err)
+	assert.Equal(t, head, strings.TrimSpace(string(out)))
+
+	// Tags of another ref point at its commit, even for annotated tags
+	require.NoError(t, g.Tag(t.Context(), "v1.1.1", "v1.1.0"))
+	out, err = exec.Command("git", "rev-parse", "v1.1.1", "v1.1.0^{commit}").Output()
+	require.NoError(t, err)
+	commits := strings.Fields(string(out))
+	assert.Equal(t, commits[0], commits[1])
+
+	assert.ErrorIs(t, g.Tag(t.Context(), "v3.0.0", "HEAD", WithMessage("release")), ErrUnsupported)
+	assert.EqualError(t, g.Tag(t.Context(), "v2.0.0", "HEAD"), "tagging the commit in git: v2.0.0: tag already exists")
+	assert.ErrorIs(t, g.Tag(t.Context(), "v1.1.0", "HEAD"), ErrTagExists)
+	assert.EqualError(t, g.Tag(t.Context(), "v3.0.0", "nope"), "tagging the commit in git: unknown revision nope")
+	for _, tag := range []string{"", "-v1", "v1..0", "v1 0", "v1.0.lock", "v1/", "v1^0", ".v1"} {
+		assert.Errorf(t, g.Tag(t.Context(), tag, "HEAD"), "Tag(%q) should fail", tag)
+	}
+}
+
+func TestNativeGitDeleteTag(t *testing.T) {
+	repoForTest(t)
+	packForTest(t)
+	g := &NativeGit{}
+	defer g.Close()
+	require.NoError(t, g.Tag(t.Context(), "v2.0.0", "HEAD"))
+
+	// A loose tag, then packed tags with and without a peeled line
+	for _, tag := range []string{"v2.0.0", "v1.0.1", "nested/v2.0.0-rc.1"} {
+		require.NoErrorf(t, g.DeleteTag(t.Context(), tag), "DeleteTag(%q)", tag)
+		err := exec.Command("git", "rev-parse", "--verify", "--quiet", "refs/tags/"+tag).Run()
+		assert.Errorf(t, err, "%s should be deleted", tag)
+	}
+	assert.EqualError(t, g.DeleteTag(t.Context(), "v2.0.0"), "deleting tag v2.0.0: tag 'v2.0.0' not found")
+	assert.ErrorIs(t, g.Push(t.Context(), "origin", "v1.1.0"), ErrUnsupported)
+	assert.ErrorIs(t, g.FetchTags(t.Context(), "origin"), ErrUnsupported)
+
+	out, err := exec.Command("git", "tag").Output()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0.0\nv1.1.0\nv2.0.0-rc.1-alias\n", string(out))
+}
+
+func TestNativeGitShallow(t *testing.T) {
+	dir := repoForTest(t)
+	clone := filepath.Join(t.TempDir(), "clone")
+	out, err := exec.Command("git", "clone", "-q", "--depth", "2", "--no-single-branch", "file://"+dir, clone).CombinedOutput()
+	require.NoErrorf(t, err, "git clone: %s", out)
+	t.Chdir(clone)
+
+	expected := &DefaultGit{CmdRunner: &DefaultCmdRunner{}}
+	actual := &NativeGit{}
+	defer actual.Close()
+	for _, g := range []Git{expected, actual} {
+		shallow, err := g.IsShallow(t.Context())
+		require.NoError(t, err)
+		assert.Truef(t, shallow, "IsShallow() of %T", g)
+	}
+
+	// The history ends at the shallow commits
+	for _, ref := range []string{"HEAD", "origin/maintenance"} {
+		expectedTags, err := expected.Tags(t.Context(), ref, true)
+		require.NoError(t, err)
+		actualTags, err := actual.Tags(t.Context(), ref, true)
+		require.NoError(t, err)
+		assert.Equalf(t, expectedTags, actualTags, "Tags(%q, true)", ref)
+
+		expectedSubjects, err := expected.CommitSubjects(t.Context(), "", ref)
+		require.NoError(t, err)
+		actualSubjects, err := actual.CommitSubjects(t.Context(), "", ref)
+		require.NoError(t, err)
+		assert.Equalf(t, expectedSubjects, actualSubjects, "CommitSubjects(%q)", ref)
+	}
+	assert.ErrorIs(t, actual.Deepen(t.Context(), "origin", 10), ErrUnsupported)
+
+	shallow, err := (&NativeGit{Dir: dir}).IsShallow(t.Context())
+	require.NoError(t, err)
+	assert.False(t, shallow)
+}
+
+func TestNativeGitDir(t *testing.T) {
+	dir := repoForTest(t)
+	require.NoError(t, os.Mkdir("sub", 0o755))
+	t.Chdir(t.TempDir())
+
+	for _, d := range []string{dir, filepath.Join(dir, "sub")} {
+		tags, err := (&NativeGit{Dir: d}).Tags(t.Context(), "HEAD", false)
+		require.NoError(t, err)
+		assert.Equalf(t, []string{"nested/v2.0.0-rc.1", "v1.0.0", "v1.0.1", "v1.1.0", "v2.0.0-rc.1-alias"}, tags, "Tags() in %s", d)
+	}
+	_, err := (&NativeGit{}).Tags(t.Context(), "HEAD", false)
+	assert.ErrorContains(t, err, "not a git repository")
+}
+
+func TestNativeGitContext(t *testing.T) {
+	repoForTest(t)
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := (&NativeGit{}).Tags(ctx, "HEAD", false)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestNativeGitNotARepository(t *testing.T) {
+	t.Chdir(t.TempDir())
+	_, err := (&NativeGit{}).Tags(t.Context(), "HEAD", false)
+	assert.ErrorIs(t, err, ErrNotARepository)
+}
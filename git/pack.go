@@ -0,0 +1,337 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// pack is a packfile and its index, see
+// https://git-scm.com/docs/gitformat-pack
+type pack struct {
+	path string
+	// ids holds the sorted object names, 20 bytes each, and offsets their
+	// position in the packfile
+	ids     []byte
+	offsets []int64
+	// file is opened on first read and kept open until close
+	file *os.File
+	// bases caches the objects deltas were applied to by offset, as
+	// deltas of the same object often share a base
+	bases     map[int64]packEntry
+	basesSize int
+}
+
+// packEntry is the type and content of an object read from a pack
+type packEntry struct {
+	typ  int
+	data []byte
+}
+
+// deltaBaseCacheLimit bounds the size of the delta bases cached by a pack,
+// like git's core.deltaBaseCacheLimit
+const deltaBaseCacheLimit = 96 << 20
+
+var (
+	idxMagic = []byte{0xff, 't', 'O', 'c'}
+
+	errCorruptPack = errors.New("corrupt packfile")
+)
+
+// openPack reads a version 1 or 2 pack index
+func openPack(index string) (*pack, error) {
+	data, err := os.ReadFile(index)
+	if err != nil {
+		return nil, err
+	}
+	p := &pack{path: strings.TrimSuffix(index, ".idx") + ".pack"}
+	corrupt := fmt.Errorf("%w: %s", errCorruptPack, index)
+
+	version := 1
+	if bytes.HasPrefix(data, idxMagic) {
+		if len(data) < 8 {
+			return nil, corrupt
+		}
+		if version = int(binary.BigEndian.Uint32(data[4:])); version != 2 {
+			return nil, fmt.Errorf("unsupported pack index version %d: %s", version, index)
+		}
+		data = data[8:]
+	}
+	if len(data) < 256*4 {
+		return nil, corrupt
+	}
+	n := int(binary.BigEndian.Uint32(data[255*4:]))
+	data = data[256*4:]
+
+	p.offsets = make([]int64, n)
+	if version == 1 {
+		if len(data) < n*24 {
+			return nil, corrupt
+		}
+		p.ids = make([]byte, 0, n*20)
+		for i := range n {
+			entry := data[i*24:]
+			p.offsets[i] = int64(binary.BigEndian.Uint32(entry))
+			p.ids = append(p.ids, entry[4:24]...)
+		}
+		return p, nil
+	}
+
+	// Names, CRC32s and 31 bit offsets, followed by the large offsets
+	if len(data) < n*28 {
+		return nil, corrupt
+	}
+	p.ids = data[:n*20]
+	offsets := data[n*24:]
+	large := data[n*28:]
+	for i := range n {
+		offset := binary.BigEndian.Uint32(offsets[i*4:])
+		if offset&0x80000000 == 0 {
+			p.offsets[i] = int64(offset)
+			continue
+		}
+		j := int(offset & 0x7fffffff)
+		if len(large) < (j+1)*8 {
+			return nil, corrupt
+		}
+		p.offsets[i] = int64(binary.BigEndian.Uint64(large[j*8:]))
+	}
+	return p, nil
+}
+
+// find returns the offset of an object in the packfile
+func (p *pack) find(id []byte) (int64, bool) {
+	n := len(p.offsets)
+	i := sort.Search(n, func(i int) bool {
+		return bytes.Compare(p.ids[i*20:i*20+20], id) >= 0
+	})
+	if i < n && bytes.Equal(p.ids[i*20:i*20+20], id) {
+		return p.offsets[i], true
+	}
+	return 0, false
+}
+
+// withPrefix returns the names of the objects starting with a hex prefix
+func (p *pack) withPrefix(prefix string) []string {
+	n := len(p.offsets)
+	name := func(i int) string {
+		return hex.EncodeToString(p.ids[i*20 : i*20+20])
+	}
+	var names []string
+	for i := sort.Search(n, func(i int) bool { return name(i) >= prefix }); i < n && strings.HasPrefix(name(i), prefix); i++ {
+		names = append(names, name(i))
+	}
+	return names
+}
+
+// readEntry returns the type and content of the object at an offset,
+// applying deltas
+func (p *pack) readEntry(r *repository, offset int64) (int, []byte, error) {
+	if p.file == nil {
+		f, err := os.Open(p.path)
+		if err != nil {
+			return 0, nil, err
+		}
+		p.file = f
+	}
+	br := bufio.NewReader(io.NewSectionReader(p.file, offset, math.MaxInt64-offset))
+	corrupt := fmt.Errorf("%w: %s at %d", errCorruptPack, p.path, offset)
+
+	b, err := br.ReadByte()
+	if err != nil {
+		return 0, nil, corrupt
+	}
+	typ := int(b>>4) & 7
+	size := uint64(b & 0x0f)
+	for shift := 4; b&0x80 != 0; shift += 7 {
+		if b, err = br.ReadByte(); err != nil || shift > 57 {
+			return 0, nil, corrupt
+		}
+		size |= uint64(b&0x7f) << shift
+	}
+
+	var baseType int
+	var base []byte
+	switch typ {
+	case objCommit, objTree, objBlob, objTag:
+		data, err := inflate(br, size)
+		if err != nil {
+			return 0, nil, corrupt
+		}
+		return typ, data, nil
+	case objOfsDelta:
+		// The base is at a negative offset, encoded with an implicit +1 per byte
+		if b, err = br.ReadByte(); err != nil {
+			return 0, nil, corrupt
+		}
+		distance := int64(b & 0x7f)
+		for b&0x80 != 0 {
+			if b, err = br.ReadByte(); err != nil {
+				return 0, nil, corrupt
+			}
+			distance = (distance+1)<<7 | int64(b&0x7f)
+		}
+		if distance <= 0 || distance > offset {
+			return 0, nil, corrupt
+		}
+		delta, err := inflate(br, size)
+		if err != nil {
+			return 0, nil, corrupt
+		}
+		if baseType, base, err = p.readBase(r, offset-distance); err != nil {
+			return 0, nil, err
+		}
+		data, err := applyDelta(base, delta)
+		return baseType, data, err
+	case objRefDelta:
+		id := make([]byte, 20)
+		if _, err = io.ReadFull(br, id); err != nil {
+			return 0, nil, corrupt
+		}
+		delta, err := inflate(br, size)
+		if err != nil {
+			return 0, nil, corrupt
+		}
+		if baseType, base, err = r.readObject(hex.EncodeToString(id)); err != nil {
+			return 0, nil, err
+		}
+		data, err := applyDelta(base, delta)
+		return baseType, data, err
+	}
+	return 0, nil, fmt.Errorf("%w: unknown object type %d", corrupt, typ)
+}
+
+// readBase returns the object at an offset that a delta applies to, from
+// the cache when it was read before. The cache is emptied when full.
+func (p *pack) readBase(r *repository, offset int64) (int, []byte, error) {
+	if base, ok := p.bases[offset]; ok {
+		return base.typ, base.data, nil
+	}
+	typ, data, err := p.readEntry(r, offset)
+	if err != nil {
+		return 0, nil, err
+	}
+	if p.bases == nil || p.basesSize+len(data) > deltaBaseCacheLimit {
+		p.bases, p.basesSize = map[int64]packEntry{}, 0
+	}
+	if len(data) <= deltaBaseCacheLimit {
+		p.bases[offset] = packEntry{typ, data}
+		p.basesSize += len(data)
+	}
+	return typ, data, nil
+}
+
+// close closes the packfile if it was opened
+func (p *pack) close() error {
+	if p.file == nil {
+		return nil
+	}
+	err := p.file.Close()
+	p.file = nil
+	return err
+}
+
+// inflate reads size bytes of zlib compressed data. The buffer grows with
+// the data read, so a corrupt size can't allocate more than the data.
+func inflate(r io.Reader, size uint64) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	var data bytes.Buffer
+	n, err := io.Copy(&data, io.LimitReader(zr, int64(min(size, math.MaxInt64))))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(n) != size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data.Bytes(), nil
+}
+
+// applyDelta rebuilds an object from its base and a delta made of copy and
+// insert instructions
+func applyDelta(base, delta []byte) ([]byte, error) {
+	corrupt := fmt.Errorf("%w: invalid delta", errCorruptPack)
+	readSize := func() (uint64, bool) {
+		var size uint64
+		for shift := 0; len(delta) > 0 && shift < 64; shift += 7 {
+			b := delta[0]
+			delta = delta[1:]
+			size |= uint64(b&0x7f) << shift
+			if b&0x80 == 0 {
+				return size, true
+			}
+		}
+		return 0, false
+	}
+
+	baseSize, ok := readSize()
+	if !ok || baseSize != uint64(len(base)) {
+		return nil, corrupt
+	}
+	size, ok := readSize()
+	if !ok {
+		return nil, corrupt
+	}
+
+	// Inserts are no longer than the delta, and the size is checked as
+	// the object is rebuilt rather than trusted for the allocation
+	out := make([]byte, 0, min(size, uint64(len(base)+len(delta))))
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+		switch {
+		case op&0x80 != 0:
+			// Copy from the base; the low bits select which offset and
+			// size bytes follow
+			var offset, n uint64
+			for i := range 7 {
+				if op&(1<<i) == 0 {
+					continue
+				}
+				if len(delta) == 0 {
+					return nil, corrupt
+				}
+				if i < 4 {
+					offset |= uint64(delta[0]) << (8 * i)
+				} else {
+					n |= uint64(delta[0]) << (8 * (i - 4))
+				}
+				delta = delta[1:]
+			}
+			if n == 0 {
+				n = 0x10000
+			}
+			if offset+n > uint64(len(base)) {
+				return nil, corrupt
+			}
+			if uint64(len(out))+n > size {
+				return nil, corrupt
+			}
+			out = append(out, base[offset:offset+n]...)
+		case op != 0:
+			if int(op) > len(delta) || uint64(len(out))+uint64(op) > size {
+				return nil, corrupt
+			}
+			out = append(out, delta[:op]...)
+			delta = delta[op:]
+		default:
+			return nil, corrupt
+		}
+	}
+	if uint64(len(out)) != size {
+		return nil, corrupt
+	}
+	return out, nil
+}
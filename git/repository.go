@@ -0,0 +1,675 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Git object types, as stored in packfiles
+const (
+	objCommit   = 1
+	objTree     = 2
+	objBlob     = 3
+	objTag      = 4
+	objOfsDelta = 6
+	objRefDelta = 7
+)
+
+// maxSymrefDepth limits the number of symbolic refs followed, like git does
+const maxSymrefDepth = 5
+
+// minAbbrevLength is the shortest abbreviated SHA that is resolved, like git
+const minAbbrevLength = 4
+
+type (
+	// repository reads refs and objects directly from a .git directory
+	repository struct {
+		// gitDir holds HEAD; commonDir holds refs and objects, and is
+		// only different from gitDir in linked worktrees
+		gitDir, commonDir string
+		packs             []*pack
+		packsLoaded       bool
+		// packed holds the packed-refs file by ref name, nil until read
+		packed map[string]packedRef
+		// shallow holds the commits of a shallow clone whose parents are
+		// missing
+		shallow map[string]bool
+	}
+
+	// packedRef is a line of the packed-refs file, with the object its tag
+	// peels to when the file records it
+	packedRef struct {
+		sha, peeled string
+		// peeledKnown is set when a missing peeled line means the ref
+		// isn't a tag object
+		peeledKnown bool
+	}
+
+	// commit is the part of a commit object used for versioning
+	commit struct {
+		parents []string
+		message string
+		// time is the committer date in seconds since the epoch
+		time int64
+	}
+)
+
+var (
+	errObjectNotFound = errors.New("object not found")
+	errNotACommit     = errors.New("not a commit")
+)
+
+// openRepository finds the repository containing dir, looking for a .git
+// directory or file in dir and its parents
+func openRepository(dir string) (*repository, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for d := dir; ; d = filepath.Dir(d) {
+		gitDir := filepath.Join(d, ".git")
+		info, err := os.Stat(gitDir)
+		switch {
+		case err == nil && info.IsDir():
+			return newRepository(gitDir)
+		case err == nil:
+			// Worktrees and submodules use a file pointing at the git directory
+			content, err := os.ReadFile(gitDir)
+			if err != nil {
+				return nil, err
+			}
+			target, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir: ")
+			if !ok {
+				return nil, fmt.Errorf("invalid gitfile format: %s", gitDir)
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(d, target)
+			}
+			return newRepository(target)
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, err
+		}
+		if filepath.Dir(d) == d {
+			return nil, fmt.Errorf("%w (or any of the parent directories): %s", ErrNotARepository, dir)
+		}
+	}
+}
+
+func newRepository(gitDir string) (*repository, error) {
+	r := &repository{gitDir: gitDir, commonDir: gitDir}
+	if content, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		r.commonDir = strings.TrimSpace(string(content))
+		if !filepath.IsAbs(r.commonDir) {
+			r.commonDir = filepath.Join(gitDir, r.commonDir)
+		}
+	}
+
+	if content, err := os.ReadFile(filepath.Join(r.commonDir, "shallow")); err == nil {
+		r.shallow = map[string]bool{}
+		for _, sha := range strings.Fields(string(content)) {
+			r.shallow[sha] = true
+		}
+	}
+
+	if config, err := os.ReadFile(filepath.Join(r.commonDir, "config")); err == nil {
+		for _, line := range strings.Split(string(config), "\n") {
+			key, value, _ := strings.Cut(strings.ToLower(line), "=")
+			if strings.TrimSpace(key) == "objectformat" && strings.TrimSpace(value) != "sha1" {
+				return nil, fmt.Errorf("unsupported object format %s", strings.TrimSpace(value))
+			}
+		}
+	}
+	return r, nil
+}
+
+// refPath returns the file of a loose ref; HEAD and other pseudo refs are
+// per worktree
+func (r *repository) refPath(name string) string {
+	if strings.HasPrefix(name, "refs/") {
+		return filepath.Join(r.commonDir, filepath.FromSlash(name))
+	}
+	return filepath.Join(r.gitDir, name)
+}
+
+// packedRefs returns the refs in the packed-refs file by name. The file is
+// read once, as resolving every tag would otherwise read it for each one.
+func (r *repository) packedRefs() (map[string]packedRef, error) {
+	if r.packed != nil {
+		return r.packed, nil
+	}
+	refs := map[string]packedRef{}
+	f, err := os.Open(filepath.Join(r.commonDir, "packed-refs"))
+	if errors.Is(err, fs.ErrNotExist) {
+		r.packed = refs
+		return refs, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// The traits of the header say which refs have peeled lines: tags with
+	// "peeled", and all refs with "fully-peeled"
+	var peeled, fullyPeeled bool
+	var last string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "# pack-refs with:"):
+			traits := strings.Fields(strings.TrimPrefix(line, "# pack-refs with:"))
+			peeled = slices.Contains(traits, "peeled")
+			fullyPeeled = slices.Contains(traits, "fully-peeled")
+		case line[0] == '#':
+		case line[0] == '^':
+			if ref, ok := refs[last]; ok && isSHA(line[1:]) {
+				ref.peeled = line[1:]
+				refs[last] = ref
+			}
+		default:
+			sha, name, ok := strings.Cut(line, " ")
+			if ok && isSHA(sha) {
+				refs[name] = packedRef{sha: sha, peeledKnown: fullyPeeled || peeled && strings.HasPrefix(name, "refs/tags/")}
+				last = name
+			}
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	r.packed = refs
+	return refs, nil
+}
+
+// removePackedRef rewrites the packed-refs file without a ref and its
+// peeled line
+func (r *repository) removePackedRef(name string) error {
+	path := filepath.Join(r.commonDir, "packed-refs")
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var kept []string
+	removed, skipPeeled := false, false
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		if skipPeeled && strings.HasPrefix(line, "^") {
+			continue
+		}
+		_, ref, _ := strings.Cut(strings.TrimSpace(line), " ")
+		skipPeeled = ref == name
+		if skipPeeled {
+			removed = true
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if !removed {
+		return nil
+	}
+	r.packed = nil
+	return writeFileLocked(path, strings.Join(kept, ""))
+}
+
+// readRef returns the SHA a ref points at, following symbolic refs
+func (r *repository) readRef(name string) (sha string, found bool, err error) {
+	for range maxSymrefDepth {
+		content, err := os.ReadFile(r.refPath(name))
+		if err != nil && !errors.Is(err, fs.ErrPermission) {
+			// Not a loose ref (missing, or a directory of refs)
+			packed, err := r.packedRefs()
+			if err != nil {
+				return "", false, err
+			}
+			ref, found := packed[name]
+			return ref.sha, found, nil
+		} else if err != nil {
+			return "", false, err
+		}
+
+		value := strings.TrimSpace(string(content))
+		if target, ok := strings.CutPrefix(value, "ref: "); ok {
+			name = target
+			continue
+		}
+		if !isSHA(value) {
+			return "", false, fmt.Errorf("invalid ref %s: %q", name, value)
+		}
+		return value, true, nil
+	}
+	return "", false, fmt.Errorf("too many levels of symbolic refs at %s", name)
+}
+
+// listRefs returns the names of the loose and packed refs starting with
+// prefix (e.g. "refs/tags/"), in sorted order
+func (r *repository) listRefs(prefix string) ([]string, error) {
+	packed, err := r.packedRefs()
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for name := range packed {
+		if strings.HasPrefix(name, prefix) {
+			names[name] = true
+		}
+	}
+
+	root := filepath.Join(r.commonDir, filepath.FromSlash(prefix))
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() || strings.HasSuffix(path, ".lock") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		names[prefix+filepath.ToSlash(rel)] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted, nil
+}
+
+// resolve returns the SHA a revision points at: a full SHA, or a ref name
+// looked up like git does (e.g. "v1.0.0" is found as refs/tags/v1.0.0)
+func (r *repository) resolve(rev string) (string, error) {
+	if isSHA(rev) {
+		if _, _, err := r.readObject(rev); err != nil {
+			return "", err
+		}
+		return rev, nil
+	}
+	for _, format := range []string{"%s", "refs/%s", "refs/tags/%s", "refs/heads/%s", "refs/remotes/%s", "refs/remotes/%s/HEAD"} {
+		// Only pseudo refs such as HEAD are looked up outside of refs/
+		if format == "%s" && !strings.HasPrefix(rev, "refs/") && strings.ToUpper(rev) != rev {
+			continue
+		}
+		name := fmt.Sprintf(format, rev)
+		sha, found, err := r.readRef(name)
+		if err != nil {
+			return "", err
+		}
+		if found {
+			return sha, nil
+		}
+	}
+	if len(rev) >= minAbbrevLength && isHex(rev) {
+		return r.expandSHA(strings.ToLower(rev))
+	}
+	if rev == "HEAD" {
+		// HEAD names a branch without commits
+		return "", fmt.Errorf("unknown revision %s: %w", rev, ErrNoCommits)
+	}
+	return "", fmt.Errorf("%w %s", ErrUnknownRevision, rev)
+}
+
+// expandSHA returns the object an abbreviated SHA names, among the loose and
+// packed objects
+func (r *repository) expandSHA(prefix string) (string, error) {
+	matches, err := r.objectsWithPrefix(prefix)
+	if err != nil {
+		return "", err
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w %s", ErrUnknownRevision, prefix)
+	case 1:
+		for name := range matches {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("short object ID %s is ambiguous", prefix)
+}
+
+// abbreviate returns the shortest prefix of a SHA, of at least minLength
+// characters, that names no other object
+func (r *repository) abbreviate(sha string, minLength int) (string, error) {
+	for n := minLength; n < len(sha); n++ {
+		matches, err := r.objectsWithPrefix(sha[:n])
+		if err != nil {
+			return "", err
+		}
+		if len(matches) <= 1 {
+			return sha[:n], nil
+		}
+	}
+	return sha, nil
+}
+
+// objectsWithPrefix returns the loose and packed objects whose names start
+// with a hex prefix
+func (r *repository) objectsWithPrefix(prefix string) (map[string]bool, error) {
+	matches := map[string]bool{}
+	entries, err := os.ReadDir(filepath.Join(r.commonDir, "objects", prefix[:2]))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if name := prefix[:2] + entry.Name(); isSHA(name) && strings.HasPrefix(name, prefix) {
+			matches[name] = true
+		}
+	}
+	if err = r.loadPacks(); err != nil {
+		return nil, err
+	}
+	for _, p := range r.packs {
+		for _, name := range p.withPrefix(prefix) {
+			matches[name] = true
+		}
+	}
+	return matches, nil
+}
+
+// peelRef returns the object a ref points at after following tag objects.
+// Packed refs are peeled with the packed-refs file, without reading the
+// objects, so the result is not checked to be a commit.
+func (r *repository) peelRef(name string) (string, error) {
+	sha, found, err := r.readRef(name)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("%w %s", ErrUnknownRevision, name)
+	}
+	// A loose ref takes precedence over a packed one
+	if ref, ok := r.packed[name]; ok && ref.sha == sha {
+		if ref.peeled != "" {
+			return ref.peeled, nil
+		} else if ref.peeledKnown {
+			return sha, nil
+		}
+	}
+	return r.peel(sha)
+}
+
+// peel follows tag objects until reaching a commit
+func (r *repository) peel(sha string) (string, error) {
+	for {
+		typ, data, err := r.readObject(sha)
+		if err != nil {
+			return "", err
+		}
+		switch typ {
+		case objCommit:
+			return sha, nil
+		case objTag:
+			target, ok := header(data, "object")
+			if !ok {
+				return "", fmt.Errorf("invalid tag object %s", sha)
+			}
+			sha = target
+		default:
+			return "", fmt.Errorf("%s is %w", sha, errNotACommit)
+		}
+	}
+}
+
+// readCommit reads and parses a commit object
+func (r *repository) readCommit(sha string) (*commit, error) {
+	typ, data, err := r.readObject(sha)
+	if err != nil {
+		return nil, err
+	}
+	if typ != objCommit {
+		return nil, fmt.Errorf("%s is %w", sha, errNotACommit)
+	}
+
+	c := &commit{}
+	headers, message, _ := bytes.Cut(data, []byte("\n\n"))
+	for _, line := range strings.Split(string(headers), "\n") {
+		if parent, ok := strings.CutPrefix(line, "parent "); ok && !r.shallow[sha] {
+			// The history of a shallow clone ends at its shallow commits
+			c.parents = append(c.parents, parent)
+		} else if committer, ok := strings.CutPrefix(line, "committer "); ok {
+			// The identity is followed by the timestamp and time zone
+			if fields := strings.Fields(committer); len(fields) >= 2 {
+				c.time, _ = strconv.ParseInt(fields[len(fields)-2], 10, 64)
+			}
+		}
+	}
+	c.message = string(message)
+	return c, nil
+}
+
+// subject returns the first paragraph of the message on a single line, like
+// git log --format=%s
+func (c *commit) subject() string {
+	paragraph, _, _ := strings.Cut(strings.TrimLeft(c.message, "\n"), "\n\n")
+	lines := strings.Split(strings.TrimSpace(paragraph), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return strings.Join(lines, " ")
+}
+
+// ancestors calls visit for a commit and each of its ancestors once, until
+// visit returns false
+func (r *repository) ancestors(sha string, visit func(sha string) bool) error {
+	seen := map[string]bool{sha: true}
+	queue := []string{sha}
+	for len(queue) > 0 {
+		sha, queue = queue[0], queue[1:]
+		if !visit(sha) {
+			return nil
+		}
+		c, err := r.readCommit(sha)
+		if err != nil {
+			return err
+		}
+		for _, parent := range c.parents {
+			if !seen[parent] {
+				seen[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return nil
+}
+
+// containing reports which of the commits have target as an ancestor (or
+// are target). Each commit is read once, so finding the tags containing a
+// commit costs one walk of the history.
+func (r *repository) containing(target string, commits []string) (map[string]bool, error) {
+	contains := map[string]bool{target: true}
+	parents := map[string][]string{}
+	for _, start := range commits {
+		stack := []string{start}
+		for len(stack) > 0 {
+			sha := stack[len(stack)-1]
+			if _, done := contains[sha]; done {
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			ps, ok := parents[sha]
+			if !ok {
+				c, err := r.readCommit(sha)
+				if errors.Is(err, errNotACommit) {
+					// Tags of trees and blobs don't contain commits
+					contains[sha] = false
+					continue
+				} else if err != nil {
+					return nil, err
+				}
+				ps = c.parents
+				parents[sha] = ps
+			}
+
+			// A commit contains target as soon as one of its parents does,
+			// and doesn't once none of them do
+			found := slices.ContainsFunc(ps, func(parent string) bool { return contains[parent] })
+			pending := false
+			for _, parent := range ps {
+				if _, done := contains[parent]; !done && !found {
+					stack = append(stack, parent)
+					pending = true
+				}
+			}
+			if !pending {
+				contains[sha] = found
+				delete(parents, sha)
+			}
+		}
+	}
+	return contains, nil
+}
+
+// readObject returns the type and content of an object
+func (r *repository) readObject(sha string) (int, []byte, error) {
+	typ, data, err := r.readLooseObject(sha)
+	if !errors.Is(err, errObjectNotFound) {
+		return typ, data, err
+	}
+
+	if err = r.loadPacks(); err != nil {
+		return 0, nil, err
+	}
+	id, err := hex.DecodeString(sha)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid object name %s", sha)
+	}
+	for _, p := range r.packs {
+		if offset, ok := p.find(id); ok {
+			return p.readEntry(r, offset)
+		}
+	}
+	return 0, nil, fmt.Errorf("%w: %s", errObjectNotFound, sha)
+}
+
+func (r *repository) readLooseObject(sha string) (int, []byte, error) {
+	if len(sha) < 3 {
+		return 0, nil, fmt.Errorf("%w: %s", errObjectNotFound, sha)
+	}
+	f, err := os.Open(filepath.Join(r.commonDir, "objects", sha[:2], sha[2:]))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil, fmt.Errorf("%w: %s", errObjectNotFound, sha)
+	} else if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return 0, nil, fmt.Errorf("reading object %s: %w", sha, err)
+	}
+	defer zr.Close()
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		return 0, nil, fmt.Errorf("reading object %s: %w", sha, err)
+	}
+
+	hdr, data, ok := bytes.Cut(content, []byte{0})
+	name, size, _ := strings.Cut(string(hdr), " ")
+	if n, err := strconv.Atoi(size); !ok || err != nil || n != len(data) {
+		return 0, nil, fmt.Errorf("corrupt object %s", sha)
+	}
+	typ, ok := map[string]int{"commit": objCommit, "tree": objTree, "blob": objBlob, "tag": objTag}[name]
+	if !ok {
+		return 0, nil, fmt.Errorf("unknown type %q of object %s", name, sha)
+	}
+	return typ, data, nil
+}
+
+// loadPacks reads the index of every packfile once
+func (r *repository) loadPacks() error {
+	if r.packsLoaded {
+		return nil
+	}
+	indexes, err := filepath.Glob(filepath.Join(r.commonDir, "objects", "pack", "*.idx"))
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		p, err := openPack(index)
+		if err != nil {
+			return err
+		}
+		r.packs = append(r.packs, p)
+	}
+	r.packsLoaded = true
+	return nil
+}
+
+// close closes the packfiles opened to read objects
+func (r *repository) close() error {
+	var errs []error
+	for _, p := range r.packs {
+		errs = append(errs, p.close())
+	}
+	return errors.Join(errs...)
+}
+
+// writeFileLocked replaces a file like git does, by writing a lock file
+// and renaming it into place
+func writeFileLocked(path, content string) error {
+	lock, err := os.OpenFile(path+".lock", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%w: unable to create '%s.lock': file exists", ErrRefLocked, path)
+	} else if err != nil {
+		return err
+	}
+	_, err = lock.WriteString(content)
+	if cerr := lock.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(lock.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(lock.Name())
+	}
+	return err
+}
+
+// header returns the value of the first header line of a commit or tag
+// object with the given name
+func header(data []byte, name string) (string, bool) {
+	headers, _, _ := bytes.Cut(data, []byte("\n\n"))
+	for _, line := range strings.Split(string(headers), "\n") {
+		if value, ok := strings.CutPrefix(line, name+" "); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// isSHA reports whether s is a full hexadecimal SHA-1
+func isSHA(s string) bool {
+	return len(s) == 40 && isHex(s)
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -14,12 +14,26 @@ var DefaultSet = wire.NewSet(
 	wire.Bind(new(Git), new(*DefaultGit)),
 )
 
+// NativeSet provides a Git that reads the repository without running git
+var NativeSet = wire.NewSet(
+	ProvideNativeGit,
+	wire.Bind(new(Git), new(*NativeGit)),
+)
+
 var buildSet = DefaultSet
 
 func NewCmdRunner() CmdRunner {
 	panic(wire.Build(buildSet))
 }
 
-func NewGit() Git {
+// NewGit returns a Git running the git command in the repository at dir,
+// the working directory when empty
+func NewGit(dir string) Git {
 	panic(wire.Build(buildSet))
 }
+
+// NewNativeGit returns a Git reading the repository at dir, the working
+// directory when empty, and a function closing it
+func NewNativeGit(dir string) (Git, func()) {
+	panic(wire.Build(NativeSet))
+}
@@ -17,16 +17,33 @@ func NewCmdRunner() CmdRunner {
 	return defaultCmdRunner
 }
 
-func NewGit() Git {
+// NewGit returns a Git running the git command in the repository at dir,
+// the working directory when empty
+func NewGit(dir string) Git {
 	defaultCmdRunner := &DefaultCmdRunner{}
 	defaultGit := &DefaultGit{
 		CmdRunner: defaultCmdRunner,
+		Dir:       dir,
 	}
 	return defaultGit
 }
 
+// NewNativeGit returns a Git reading the repository at dir, the working
+// directory when empty, and a function closing it
+func NewNativeGit(dir string) (Git, func()) {
+	nativeGit, cleanup := ProvideNativeGit(dir)
+	return nativeGit, func() {
+		cleanup()
+	}
+}
+
 // wire.go:
 
 var DefaultSet = wire.NewSet(wire.Struct(new(DefaultCmdRunner), "*"), wire.Struct(new(DefaultGit), "*"), wire.Bind(new(CmdRunner), new(*DefaultCmdRunner)), wire.Bind(new(Git), new(*DefaultGit)))
 
+// NativeSet provides a Git that reads the repository without running git
+var NativeSet = wire.NewSet(
+	ProvideNativeGit, wire.Bind(new(Git), new(*NativeGit)),
+)
+
 var buildSet = DefaultSet
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/screwdriver-cd/gitversion/bumper"
+	"github.com/screwdriver-cd/gitversion/version"
 	"github.com/urfave/cli/v2"
 )
 
@@ -17,9 +25,18 @@ var (
 	DATE    = "unknown"
 )
 
+// Exit codes of the compare command; 1 is used for errors
+const (
+	compareOlder = 2
+	compareNewer = 3
+)
+
 func main() {
-	var prefix string
-	var merged, dryrun bool
+	var prefix, constraint, schemeName, calverLayout, format, preID, base, buildMetadata, backend, message string
+	var signingKey, signFormat, remote, ref, repo, deepen string
+	var merged, dryrun, printField, requireHead, jsonReport, annotate, sign, push, fetch, requireTags bool
+	var components int
+	var timeout time.Duration
 
 	app := cli.NewApp()
 	app.Name = "gitversion"
@@ -27,6 +44,12 @@ func main() {
 	app.Version = fmt.Sprintf("%v, commit %v, built at %v", VERSION, COMMIT, DATE)
 
 	app.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "repo",
+			Usage:       "use the repository at a path instead of the working directory",
+			Aliases:     []string{"C"},
+			Destination: &repo,
+		},
 		&cli.StringFlag{
 			Name:        "prefix",
 			Usage:       "set a prefix for the tag name (e.g. v1.0.0)",
@@ -34,34 +57,263 @@ func main() {
 		},
 		&cli.BoolFlag{
 			Name:        "merged",
-			Usage:       "consider tags merged into this branch",
+			Usage:       "consider tags merged into the ref",
 			Destination: &merged,
 		},
+		&cli.StringFlag{
+			Name:        "ref",
+			Usage:       "use the commit a ref (e.g. a branch or a SHA) points at instead of HEAD",
+			Value:       "HEAD",
+			Destination: &ref,
+		},
+		&cli.StringFlag{
+			Name:        "scheme",
+			Usage:       fmt.Sprintf("set the versioning scheme [%s]", strings.Join(version.SchemeNames(), ", ")),
+			Value:       version.DefaultScheme.Name(),
+			Destination: &schemeName,
+		},
+		&cli.StringFlag{
+			Name:        "calver-layout",
+			Usage:       "set the layout of calver versions (e.g. YY.0W.MICRO)",
+			Value:       version.DefaultCalVerLayout,
+			Destination: &calverLayout,
+		},
+		&cli.StringFlag{
+			Name:        "build-metadata",
+			Usage:       "set the build metadata from a template (e.g. 'sd.{{.Env.SD_BUILD_ID}}.g{{.ShortCommit}}')",
+			Destination: &buildMetadata,
+		},
+		&cli.StringFlag{
+			Name:        "backend",
+			Usage:       "set how git repositories are read [exec, native]; native does not need the git command",
+			Value:       "exec",
+			Destination: &backend,
+		},
+		&cli.IntFlag{
+			Name:        "components",
+			Usage:       "set the number of numeric version components (2-4)",
+			Value:       version.DefaultComponents,
+			Destination: &components,
+		},
+		&cli.DurationFlag{
+			Name:        "timeout",
+			Usage:       "stop git commands after a duration (e.g. 30s); no limit when 0",
+			Destination: &timeout,
+		},
+	}
+
+	// lookupScheme returns the named scheme, configured from the global flags
+	lookupScheme := func(name string) (version.Scheme, error) {
+		scheme, err := version.LookupScheme(name)
+		if err == nil && scheme.Name() == "calver" {
+			scheme, err = version.NewCalVer(calverLayout, time.Now)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return version.WithComponents(scheme, components)
+	}
+
+	var scheme version.Scheme
+	var newBumper func(version.Scheme, string) (bumper.Bumper, func())
+	var cancelTimeout context.CancelFunc = func() {}
+	app.Before = func(context *cli.Context) (err error) {
+		context.Context, cancelTimeout = withTimeout(context.Context, timeout)
+		switch backend {
+		case "exec":
+			newBumper = bumper.NewBumper
+		case "native":
+			newBumper = bumper.NewNativeBumper
+		default:
+			err = fmt.Errorf("unknown backend %q", backend)
+			log.Printf("Error: %v", err)
+			return err
+		}
+		if scheme, err = lookupScheme(schemeName); err != nil {
+			log.Printf("Error: %v", err)
+		}
+		return err
+	}
+	app.After = func(*cli.Context) error {
+		cancelTimeout()
+		return nil
 	}
 
 	bumpWithFieldAction := func(field bumper.Field) cli.ActionFunc {
 		return func(context *cli.Context) error {
-			b := bumper.NewBumper()
-			return b.Bump(
+			var baseField bumper.Field
+			if base != "" {
+				var err error
+				if baseField, err = bumper.ParseField(base); err != nil {
+					log.Printf("Error: %v", err)
+					return err
+				}
+			}
+			deepenMode, err := bumper.ParseDeepen(deepen)
+			if err != nil {
+				log.Printf("Error: %v", err)
+				return err
+			}
+
+			b, cleanup := newBumper(scheme, repo)
+			defer cleanup()
+			err = b.Bump(
+				context.Context,
 				bumper.WithPrefix(prefix),
 				bumper.WithField(field),
 				bumper.WithMerged(merged),
+				bumper.WithRef(ref),
 				bumper.WithDryRun(dryrun),
+				bumper.WithPreID(preID),
+				bumper.WithBase(baseField),
+				bumper.WithRequireHead(requireHead),
+				bumper.WithBuildMetadata(buildMetadata),
+				bumper.WithAnnotate(annotate),
+				bumper.WithMessage(message),
+				bumper.WithSign(sign),
+				bumper.WithSigningKey(signingKey),
+				bumper.WithSignFormat(signFormat),
+				bumper.WithPush(push),
+				bumper.WithRemote(remote),
+				bumper.WithFetch(fetch),
+				bumper.WithRequireTags(requireTags),
+				bumper.WithDeepen(deepenMode),
 			)
+			if err != nil {
+				log.Printf("Error: %v", err)
+			}
+			return err
+		}
+	}
+
+	// showAction outputs the latest version, after checking the signature of
+	// its tag if verify is set
+	showAction := func(verify bool) cli.ActionFunc {
+		return func(context *cli.Context) error {
+			formatter := scheme
+			if format != "" {
+				var err error
+				if formatter, err = lookupScheme(format); err != nil {
+					log.Printf("Error: %v", err)
+					return err
+				}
+			}
+			// The version is output in the format of formatter, which must
+			// be able to write the build metadata
+			if buildMetadata != "" {
+				if err := version.CheckBuildMetadata(formatter); err != nil {
+					log.Printf("Error: %v", err)
+					return err
+				}
+			}
+
+			b, cleanup := newBumper(scheme, repo)
+			defer cleanup()
+			latest, err := latestMatching(context.Context, b, scheme, prefix, ref, merged, constraint)
+			if err != nil {
+				log.Printf("Error: %v", err)
+				return err
+			}
+			v := latest.Version
+
+			if verify {
+				if err = b.VerifyTag(context.Context, latest.Tag); err != nil {
+					log.Printf("Error: %v", err)
+					return err
+				}
+			}
+
+			if buildMetadata != "" {
+				if v.Build, err = b.BuildMetadata(context.Context, buildMetadata, ref); err != nil {
+					log.Printf("Error: %v", err)
+					return err
+				}
+			}
+			_, err = fmt.Printf("%s%s\n", prefix, formatter.Format(v))
+			return err
 		}
 	}
 
-	var latestAction cli.ActionFunc = func(context *cli.Context) error {
-		b := bumper.NewBumper()
-		v, err := b.LatestVersion(prefix, merged)
+	var compareAction cli.ActionFunc = func(context *cli.Context) error {
+		if context.NArg() != 2 {
+			err := fmt.Errorf("expected two versions, got %d", context.NArg())
+			log.Printf("Error: %v", err)
+			return err
+		}
+
+		b, cleanup := newBumper(scheme, repo)
+		defer cleanup()
+		var versions [2]version.Version
+		for i := range versions {
+			var err error
+			if versions[i], err = b.Parse(prefix, context.Args().Get(i)); err != nil {
+				log.Printf("Error: %v", err)
+				return err
+			}
+		}
+
+		if printField {
+			if _, err := fmt.Println(version.Diff(versions[0], versions[1])); err != nil {
+				return err
+			}
+		}
+		switch scheme.Compare(versions[0], versions[1]) {
+		case -1:
+			return cli.Exit("", compareOlder)
+		case 1:
+			return cli.Exit("", compareNewer)
+		}
+		return nil
+	}
+
+	var lintAction cli.ActionFunc = func(context *cli.Context) error {
+		b, cleanup := newBumper(scheme, repo)
+		defer cleanup()
+		issues, err := b.Lint(context.Context, prefix, ref, merged)
 		if err != nil {
 			log.Printf("Error: %v", err)
 			return err
 		}
-		_, err = fmt.Printf("%s%s\n", prefix, v)
+
+		if jsonReport {
+			report := struct {
+				Issues []bumper.LintIssue `json:"issues"`
+			}{Issues: issues}
+			if report.Issues == nil {
+				report.Issues = []bumper.LintIssue{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err = enc.Encode(report); err != nil {
+				return err
+			}
+		} else {
+			for _, issue := range issues {
+				fmt.Printf("%s: %s: %s\n", issue.Tag, issue.Problem, issue.Message)
+			}
+		}
+
+		if len(issues) > 0 {
+			err = fmt.Errorf("found %d problems with tags", len(issues))
+			log.Printf("Error: %v", err)
+		}
 		return err
 	}
 
+	showFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:        "constraint",
+			Usage:       "only consider versions matching a constraint (e.g. '^1.4' or '>=1.2.0 <2.0.0')",
+			Destination: &constraint,
+			Aliases:     []string{"c"},
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       fmt.Sprintf("output the version in the format of another scheme [%s]", strings.Join(version.SchemeNames(), ", ")),
+			Destination: &format,
+		},
+	}
+
 	app.Commands = []*cli.Command{
 		{
 			Name:    "bump",
@@ -74,13 +326,85 @@ func main() {
 					Destination: &dryrun,
 					Aliases:     []string{"n"},
 				},
+				&cli.BoolFlag{
+					Name:        "annotate",
+					Usage:       "create an annotated tag",
+					Destination: &annotate,
+					Aliases:     []string{"a"},
+				},
+				&cli.StringFlag{
+					Name:        "message",
+					Usage:       "set the message of an annotated tag from a template (e.g. '{{.Tag}}: {{.Field}} bump from {{.Previous}}')",
+					Destination: &message,
+					Aliases:     []string{"m"},
+				},
+				&cli.BoolFlag{
+					Name:        "sign",
+					Usage:       "create a signed tag (see git tag -s)",
+					Destination: &sign,
+					Aliases:     []string{"s"},
+				},
+				&cli.StringFlag{
+					Name:        "sign-key",
+					Usage:       "sign the tag with a key instead of the default one (e.g. a GPG key ID or an SSH key file)",
+					Destination: &signingKey,
+				},
+				&cli.StringFlag{
+					Name:        "sign-format",
+					Usage:       "set the signature format instead of the gpg.format git config [openpgp, x509, ssh]",
+					Destination: &signFormat,
+				},
+				&cli.BoolFlag{
+					Name:        "push",
+					Usage:       "push the new tag to the remote; the local tag is deleted if the push fails",
+					Destination: &push,
+				},
+				&cli.StringFlag{
+					Name:        "remote",
+					Usage:       "set the remote tags and history are fetched from and the new tag is pushed to",
+					Value:       "origin",
+					Destination: &remote,
+				},
+				&cli.BoolFlag{
+					Name:        "fetch",
+					Usage:       "fetch the tags of the remote before finding the latest version",
+					Destination: &fetch,
+				},
+				&cli.BoolFlag{
+					Name:        "require-tags",
+					Usage:       "fail when there are no version tags instead of starting from the zero version",
+					Destination: &requireTags,
+				},
+				&cli.StringFlag{
+					Name:        "deepen",
+					Usage:       "fetch the history of a shallow clone from the remote until a version tag is found instead of failing [never, auto]",
+					Value:       "never",
+					Destination: &deepen,
+				},
 			},
 			Subcommands: []*cli.Command{
 				{
-					Name:   "prerelease",
-					Usage:  "bump the prerelease version",
+					Name:  "prerelease",
+					Usage: "bump the prerelease version",
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:        "preid",
+							Usage:       "number prereleases with an identifier (e.g. rc for 1.3.0-rc.1) instead of using the commit SHA",
+							Destination: &preID,
+						},
+						&cli.StringFlag{
+							Name:        "base",
+							Usage:       "start a numbered prerelease by bumping the latest release, with --preid [major, minor, patch, revision]",
+							Destination: &base,
+						},
+					},
 					Action: bumpWithFieldAction(bumper.FieldPrerelease),
 				},
+				{
+					Name:   "revision",
+					Usage:  "bump the revision (fourth) version component",
+					Action: bumpWithFieldAction(bumper.FieldRevision),
+				},
 				{
 					Name:   "patch",
 					Usage:  "bump the patch version",
@@ -96,6 +420,24 @@ func main() {
 					Usage:  "bump the major version",
 					Action: bumpWithFieldAction(bumper.FieldMajor),
 				},
+				{
+					Name:   "snapshot",
+					Usage:  "bump to the next -SNAPSHOT version (maven scheme)",
+					Action: bumpWithFieldAction(bumper.FieldSnapshot),
+				},
+				{
+					Name:    "release",
+					Aliases: []string{"promote"},
+					Usage:   "release the latest prerelease (e.g. 1.3.0-rc.3 becomes 1.3.0)",
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:        "require-head",
+							Usage:       "fail unless HEAD is the commit tagged with the prerelease",
+							Destination: &requireHead,
+						},
+					},
+					Action: bumpWithFieldAction(bumper.FieldRelease),
+				},
 				{
 					Name:   "auto",
 					Usage:  "bump the version specified in the last commit",
@@ -107,15 +449,89 @@ func main() {
 			Name:    "show",
 			Aliases: []string{"s"},
 			Usage:   "output the latest tagged version",
-			Action:  latestAction,
+			Flags:   showFlags,
+			Action:  showAction(false),
+		},
+		{
+			Name:   "verify",
+			Usage:  "check the signature of the latest version tag and output the version",
+			Flags:  showFlags,
+			Action: showAction(true),
+		},
+		{
+			Name:      "compare",
+			Aliases:   []string{"c"},
+			Usage:     "compare two versions; exits with 0 if A equals B, 2 if A is older and 3 if A is newer",
+			ArgsUsage: "A B",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:        "print-field",
+					Usage:       "output the most significant field that differs (none, major, minor, patch, revision, prerelease or build)",
+					Destination: &printField,
+				},
+			},
+			Action: compareAction,
+		},
+		{
+			Name:  "lint",
+			Usage: "report tags that can't be parsed, duplicate versions and versions out of order with commit ancestry",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:        "json",
+					Usage:       "output the report as JSON",
+					Destination: &jsonReport,
+				},
+			},
+			Action: lintAction,
 		},
 	}
 
-	app.Action = latestAction
+	app.Action = showAction(false)
+
+	// Interrupting gitversion kills the running git command
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 
 	// if Run receives an error, the error message is already printed out to
 	// stderr, but we should exit with an error code
-	if err := app.Run(os.Args); err != nil {
+	err := app.RunContext(ctx, os.Args)
+	stop()
+	if err != nil {
 		os.Exit(1)
 	}
 }
+
+// withTimeout returns a context that is cancelled after the timeout, or only
+// when the parent is when it is 0
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// latestMatching returns the latest tagged version, which satisfies the
+// constraint expression unless it is empty
+func latestMatching(ctx context.Context, b bumper.Bumper, scheme version.Scheme, prefix, ref string, merged bool, expr string) (latest bumper.TaggedVersion, err error) {
+	var c version.Constraint
+	if expr != "" {
+		if c, err = version.ParseConstraint(expr); err != nil {
+			return latest, err
+		}
+	}
+	versions, err := b.TaggedVersions(ctx, prefix, ref, merged)
+	if err != nil {
+		return latest, err
+	}
+	if expr != "" {
+		versions = slices.DeleteFunc(versions, func(t bumper.TaggedVersion) bool {
+			return !c.Check(t.Version)
+		})
+		if len(versions) == 0 {
+			return latest, fmt.Errorf("no versions match %q", c)
+		}
+	}
+
+	return slices.MaxFunc(versions, func(a, b bumper.TaggedVersion) int {
+		return scheme.Compare(a.Version, b.Version)
+	}), nil
+}
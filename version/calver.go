@@ -0,0 +1,259 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultCalVerLayout is the layout used by the "calver" scheme unless another one is given
+const DefaultCalVerLayout = "YYYY.MM.MICRO"
+
+// CalVer is a https://calver.org Scheme. Its layout is a dot-separated list
+// of date segments followed by a MICRO counter, e.g. YYYY.MM.MICRO or
+// YY.0W.MICRO. The segments are stored in Major, Minor and Patch in order
+// and an optional -<modifier> suffix in PreRelease.
+//
+// Bumping any numeric field sets the date segments from the clock and resets
+// MICRO to 0 when the period has changed, or increments MICRO otherwise.
+type CalVer struct {
+	layout string
+	tokens []calToken
+	now    func() time.Time
+}
+
+// A calToken is a single segment of a CalVer layout
+type calToken string
+
+const (
+	tokenFullYear  calToken = "YYYY"
+	tokenShortYear calToken = "YY"
+	tokenPadYear   calToken = "0Y"
+	tokenMonth     calToken = "MM"
+	tokenPadMonth  calToken = "0M"
+	tokenWeek      calToken = "WW"
+	tokenPadWeek   calToken = "0W"
+	tokenDay       calToken = "DD"
+	tokenPadDay    calToken = "0D"
+	tokenMicro     calToken = "MICRO"
+)
+
+var (
+	_ Scheme = CalVer{}
+
+	// ErrInvalidLayout is returned for CalVer layouts that cannot be used
+	ErrInvalidLayout = errors.New("invalid calver layout")
+)
+
+// NewCalVer returns a CalVer Scheme for a layout. The now function provides
+// the current date when bumping; time.Now is used when it is nil.
+func NewCalVer(layout string, now func() time.Time) (c CalVer, err error) {
+	segments := strings.Split(layout, ".")
+	if len(segments) < 2 || len(segments) > 3 {
+		return c, fmt.Errorf("%w %q: must contain 2 or 3 segments", ErrInvalidLayout, layout)
+	}
+	for i, s := range segments {
+		t := calToken(s)
+		switch t {
+		case tokenFullYear, tokenShortYear, tokenPadYear,
+			tokenMonth, tokenPadMonth,
+			tokenWeek, tokenPadWeek,
+			tokenDay, tokenPadDay:
+			if i == len(segments)-1 {
+				return c, fmt.Errorf("%w %q: must end with %s", ErrInvalidLayout, layout, tokenMicro)
+			}
+		case tokenMicro:
+			if i != len(segments)-1 {
+				return c, fmt.Errorf("%w %q: %s must be the last segment", ErrInvalidLayout, layout, tokenMicro)
+			}
+		default:
+			return c, fmt.Errorf("%w %q: unknown segment %q", ErrInvalidLayout, layout, s)
+		}
+		c.tokens = append(c.tokens, t)
+	}
+	if now == nil {
+		now = time.Now
+	}
+	c.layout = layout
+	c.now = now
+	return c, nil
+}
+
+// mustCalVer returns a CalVer for a layout that is known to be valid
+func mustCalVer(layout string) CalVer {
+	c, err := NewCalVer(layout, nil)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Name implements Scheme.Name()
+func (CalVer) Name() string {
+	return "calver"
+}
+
+// Layout returns the layout the scheme was created with
+func (c CalVer) Layout() string {
+	return c.layout
+}
+
+// Parse implements Scheme.Parse()
+func (c CalVer) Parse(s string) (ver Version, err error) {
+	core, modifier, hasModifier := strings.Cut(s, "-")
+	segments := strings.Split(core, ".")
+	if len(segments) != len(c.tokens) {
+		return ver, &ParseError{Input: s, Err: fmt.Errorf("%w: expected %s", ErrComponentCount, c.layout)}
+	}
+
+	nums := make([]int, len(segments))
+	for i, seg := range segments {
+		if nums[i], err = c.tokens[i].parse(seg); err != nil {
+			return ver, &ParseError{Input: s, Err: err}
+		}
+	}
+	if hasModifier {
+		if err = validateIdentifiers(modifier, true); err != nil {
+			return ver, &ParseError{Input: s, Err: fmt.Errorf("modifier: %w", err)}
+		}
+	}
+
+	ver = c.fromSegments(nums)
+	ver.PreRelease = modifier
+	return ver, nil
+}
+
+// Format implements Scheme.Format()
+func (c CalVer) Format(v Version) string {
+	nums := c.segments(v)
+	parts := make([]string, len(nums))
+	for i, n := range nums {
+		parts[i] = c.tokens[i].format(n)
+	}
+	s := strings.Join(parts, ".")
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	return s
+}
+
+// Compare implements Scheme.Compare()
+func (CalVer) Compare(a, b Version) int {
+	return Compare(a, b)
+}
+
+// Bump implements Scheme.Bump(). Every numeric field bumps the version to
+// the current period; the modifier is dropped from the result.
+func (c CalVer) Bump(v Version, field string) (Version, error) {
+	switch field {
+	default:
+		return v, ErrUnknownField
+	case "major", "minor", "patch":
+	}
+
+	nums := c.segments(v)
+	current := c.dateSegments(c.now())
+	next := make([]int, len(nums))
+	copy(next, current)
+
+	micro := len(nums) - 1
+	if slices.Compare(current, nums[:micro]) <= 0 {
+		// Still in the same period (or the clock is behind the latest tag)
+		copy(next, nums[:micro])
+		next[micro] = nums[micro] + 1
+	}
+	return c.fromSegments(next), nil
+}
+
+// dateSegments returns the values of the date segments of the layout for t
+func (c CalVer) dateSegments(t time.Time) []int {
+	year, week := t.ISOWeek()
+	if !c.hasWeek() {
+		year = t.Year()
+	}
+
+	nums := make([]int, 0, len(c.tokens)-1)
+	for _, tok := range c.tokens[:len(c.tokens)-1] {
+		switch tok {
+		case tokenFullYear:
+			nums = append(nums, year)
+		case tokenShortYear, tokenPadYear:
+			nums = append(nums, year-2000)
+		case tokenMonth, tokenPadMonth:
+			nums = append(nums, int(t.Month()))
+		case tokenWeek, tokenPadWeek:
+			nums = append(nums, week)
+		case tokenDay, tokenPadDay:
+			nums = append(nums, t.Day())
+		}
+	}
+	return nums
+}
+
+// hasWeek returns true if the layout uses weeks, in which case years are ISO week years
+func (c CalVer) hasWeek() bool {
+	for _, t := range c.tokens {
+		if t == tokenWeek || t == tokenPadWeek {
+			return true
+		}
+	}
+	return false
+}
+
+// segments returns the layout segments stored in v
+func (c CalVer) segments(v Version) []int {
+	return []int{v.Major, v.Minor, v.Patch}[:len(c.tokens)]
+}
+
+// fromSegments stores layout segments in a Version
+func (c CalVer) fromSegments(nums []int) (v Version) {
+	fields := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, n := range nums {
+		*fields[i] = n
+	}
+	return v
+}
+
+// parse parses a segment, checking that it is padded and in range as the token requires
+func (t calToken) parse(s string) (int, error) {
+	padded := t == tokenPadYear || t == tokenPadMonth || t == tokenPadWeek || t == tokenPadDay
+	if padded {
+		if len(s) != 2 {
+			return 0, fmt.Errorf("%w: %q is not two digits for %s", ErrInvalidNumber, s, t)
+		}
+		s = strings.TrimPrefix(s, "0")
+	}
+	n, err := parseNumeric(s)
+	if err != nil {
+		return 0, err
+	}
+
+	lo, hi := 0, -1
+	switch t {
+	case tokenFullYear:
+		lo, hi = 1000, 9999
+	case tokenShortYear, tokenPadYear:
+		lo, hi = 0, 999
+	case tokenMonth, tokenPadMonth:
+		lo, hi = 1, 12
+	case tokenWeek, tokenPadWeek:
+		lo, hi = 1, 53
+	case tokenDay, tokenPadDay:
+		lo, hi = 1, 31
+	}
+	if n < lo || (hi >= 0 && n > hi) {
+		return 0, fmt.Errorf("%w: %q is out of range for %s", ErrInvalidNumber, s, t)
+	}
+	return n, nil
+}
+
+// format formats a segment, padding it to two digits if the token requires
+func (t calToken) format(n int) string {
+	switch t {
+	case tokenPadYear, tokenPadMonth, tokenPadWeek, tokenPadDay:
+		return fmt.Sprintf("%02d", n)
+	}
+	return fmt.Sprint(n)
+}
@@ -0,0 +1,119 @@
+package version
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func calVerForTest(t *testing.T, layout string, now string) CalVer {
+	t.Helper()
+	date, err := time.Parse(time.DateOnly, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewCalVer(layout, func() time.Time { return date })
+	if err != nil {
+		t.Fatalf("NewCalVer(%q) error = %v", layout, err)
+	}
+	return c
+}
+
+func TestNewCalVerErrors(t *testing.T) {
+	for _, layout := range []string{
+		"YYYY",
+		"YYYY.MM",
+		"YYYY.MM.DD.MICRO",
+		"YYYY.MICRO.MM",
+		"YYYY.QQ.MICRO",
+	} {
+		if _, err := NewCalVer(layout, nil); !errors.Is(err, ErrInvalidLayout) {
+			t.Errorf("NewCalVer(%q) error = %v, want %v", layout, err, ErrInvalidLayout)
+		}
+	}
+}
+
+func TestCalVerParse(t *testing.T) {
+	var tests = []struct {
+		layout string
+		input  string
+		want   Version
+		valid  bool
+	}{
+		{"YYYY.MM.MICRO", "2024.3.0", Version{2024, 3, 0, 0, "", ""}, true},
+		{"YYYY.MM.MICRO", "2024.12.7-dev", Version{2024, 12, 7, 0, "dev", ""}, true},
+		{"YYYY.MM.MICRO", "2024.03.0", Version{}, false},
+		{"YYYY.MM.MICRO", "2024.13.0", Version{}, false},
+		{"YYYY.MM.MICRO", "24.3.0", Version{}, false},
+		{"YYYY.MM.MICRO", "2024.3", Version{}, false},
+		{"YYYY.0M.MICRO", "2024.03.1", Version{2024, 3, 1, 0, "", ""}, true},
+		{"YYYY.0M.MICRO", "2024.3.1", Version{}, false},
+		{"YY.0W.MICRO", "24.09.2", Version{24, 9, 2, 0, "", ""}, true},
+		{"YY.0W.MICRO", "2024.09.2", Version{}, false},
+		{"YYYY.MICRO", "2024.5", Version{2024, 5, 0, 0, "", ""}, true},
+	}
+	for _, test := range tests {
+		c, _ := NewCalVer(test.layout, nil)
+		v, err := c.Parse(test.input)
+		if test.valid != (err == nil) {
+			t.Errorf("%s Parse(%q) error = %v", test.layout, test.input, err)
+		}
+		if v != test.want {
+			t.Errorf("%s Parse(%q) = %v, want %v", test.layout, test.input, v, test.want)
+		}
+		if test.valid {
+			if got := c.Format(v); got != test.input {
+				t.Errorf("%s Format(%v) = %q, want %q", test.layout, v, got, test.input)
+			}
+		}
+	}
+}
+
+func TestCalVerBump(t *testing.T) {
+	var tests = []struct {
+		layout string
+		now    string
+		input  string
+		want   string
+	}{
+		{"YYYY.MM.MICRO", "2024-03-15", "2024.2.4", "2024.3.0"},
+		{"YYYY.MM.MICRO", "2024-03-15", "2024.3.0", "2024.3.1"},
+		{"YYYY.MM.MICRO", "2024-03-15", "2024.3.1-dev", "2024.3.2"},
+		{"YYYY.MM.MICRO", "2024-03-15", "2024.4.0", "2024.4.1"},
+		{"YYYY.0M.MICRO", "2024-03-15", "2023.12.9", "2024.03.0"},
+		{"YY.0W.MICRO", "2024-03-15", "24.10.3", "24.11.0"},
+		{"YY.0W.MICRO", "2024-03-15", "24.11.3", "24.11.4"},
+		// 2027-01-01 is in the last ISO week of 2026
+		{"YY.0W.MICRO", "2027-01-01", "26.52.0", "26.53.0"},
+		{"YYYY.MICRO", "2024-03-15", "2024.5", "2024.6"},
+	}
+	for _, test := range tests {
+		c := calVerForTest(t, test.layout, test.now)
+		v, err := c.Parse(test.input)
+		if err != nil {
+			t.Fatalf("%s Parse(%q) error = %v", test.layout, test.input, err)
+		}
+		bumped, err := c.Bump(v, "patch")
+		if err != nil {
+			t.Errorf("%s Bump(%q) error = %v", test.layout, test.input, err)
+		}
+		if got := c.Format(bumped); got != test.want {
+			t.Errorf("%s Bump(%q) on %s = %q, want %q", test.layout, test.input, test.now, got, test.want)
+		}
+	}
+}
+
+func TestCalVerBumpNoVersion(t *testing.T) {
+	c := calVerForTest(t, "YYYY.MM.MICRO", "2024-03-15")
+	bumped, err := c.Bump(Version{}, "minor")
+	if err != nil {
+		t.Fatalf("Bump() error = %v", err)
+	}
+	if got := c.Format(bumped); got != "2024.3.0" {
+		t.Errorf("Bump() = %q, want %q", got, "2024.3.0")
+	}
+
+	if _, err := c.Bump(Version{}, "foobar"); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("Bump(%q) error = %v, want %v", "foobar", err, ErrUnknownField)
+	}
+}
@@ -0,0 +1,288 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A Constraint is a set of version ranges parsed from an expression such as
+// ">=1.2.0 <2.0.0", "^1.4", "~1.4.2", "1.x" or "!=1.3.0".
+//
+// Space or comma separated terms must all match; alternatives may be joined
+// with "||". Ranges with an implied upper bound (^, ~, x and partial
+// versions) exclude prereleases of that bound, so "^1.4" does not match
+// 2.0.0-rc.1.
+type Constraint struct {
+	raw  string
+	sets [][]term
+}
+
+// A term is a single range, optionally negated
+type term struct {
+	lo, hi         *Version
+	loIncl, hiIncl bool
+	negate         bool
+}
+
+// partial is a possibly incomplete version such as 1, 1.4 or 1.4.x
+type partial struct {
+	v     Version
+	parts int
+}
+
+var (
+	// ErrInvalidConstraint is returned for malformed constraint expressions
+	ErrInvalidConstraint = errors.New("invalid constraint")
+
+	operators = []string{"==", "!=", ">=", "<=", ">", "<", "=", "^", "~"}
+)
+
+// ParseConstraint parses a constraint expression
+func ParseConstraint(s string) (c Constraint, err error) {
+	c.raw = s
+	for _, alt := range strings.Split(s, "||") {
+		terms, err := parseTerms(alt)
+		if err != nil {
+			return c, fmt.Errorf("parsing constraint %q: %w", s, err)
+		}
+		c.sets = append(c.sets, terms)
+	}
+	return c, nil
+}
+
+// Check returns true if v satisfies the constraint
+func (c Constraint) Check(v Version) bool {
+	for _, set := range c.sets {
+		matched := true
+		for _, t := range set {
+			if !t.check(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the expression the constraint was parsed from
+func (c Constraint) String() string {
+	return c.raw
+}
+
+// Filter returns the versions that satisfy the constraint
+func (v List) Filter(c Constraint) List {
+	ret := List{}
+	for _, ver := range v {
+		if c.Check(ver) {
+			ret = append(ret, ver)
+		}
+	}
+	return ret
+}
+
+func parseTerms(s string) ([]term, error) {
+	fields := strings.Fields(strings.ReplaceAll(s, ",", " "))
+	if len(fields) == 0 {
+		// An empty alternative matches everything, like "*"
+		return []term{{}}, nil
+	}
+
+	var terms []term
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		// Allow whitespace between an operator and its version (">= 1.2.0")
+		if isOperator(f) {
+			if i+1 == len(fields) {
+				return nil, fmt.Errorf("%w: operator %q without a version", ErrInvalidConstraint, f)
+			}
+			i++
+			f += fields[i]
+		}
+		t, err := parseTerm(f)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+	}
+	return terms, nil
+}
+
+func isOperator(s string) bool {
+	for _, op := range operators {
+		if s == op {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTerm(s string) (t term, err error) {
+	op := ""
+	for _, o := range operators {
+		if strings.HasPrefix(s, o) {
+			op = o
+			break
+		}
+	}
+
+	p, err := parsePartial(s[len(op):])
+	if err != nil {
+		return t, err
+	}
+
+	lo := p.v
+	hi := p.next()
+	switch op {
+	case "", "=", "==":
+		t = p.rangeTerm()
+	case "!=":
+		t = p.rangeTerm()
+		t.negate = true
+	case ">":
+		if p.parts == 0 {
+			return t, fmt.Errorf("%w: %q matches nothing", ErrInvalidConstraint, s)
+		}
+		if p.full() {
+			t = term{lo: &lo}
+		} else {
+			t = term{lo: hi, loIncl: true}
+		}
+	case ">=":
+		t = term{lo: &lo, loIncl: true}
+	case "<":
+		if !p.full() {
+			lo.PreRelease = "0"
+		}
+		t = term{hi: &lo}
+	case "<=":
+		if p.full() {
+			t = term{hi: &lo, hiIncl: true}
+		} else {
+			t = term{hi: hi}
+		}
+	case "^":
+		t = term{lo: &lo, loIncl: true, hi: p.caret()}
+	case "~":
+		t = term{lo: &lo, loIncl: true, hi: p.tilde()}
+	}
+	if p.parts == 0 && op != "!=" {
+		// "*" and friends match everything regardless of the operator
+		t = term{}
+	}
+	return t, nil
+}
+
+func parsePartial(s string) (p partial, err error) {
+	if s == "" {
+		return p, fmt.Errorf("%w: missing version", ErrInvalidConstraint)
+	}
+
+	core, suffix := s, ""
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		core, suffix = s[:i], s[i:]
+	}
+
+	components := strings.Split(core, ".")
+	if len(components) > MaxComponents {
+		return p, &ParseError{Input: s, Err: ErrComponentCount}
+	}
+
+	nums := make([]int, 0, MaxComponents)
+	for _, c := range components {
+		if c == "x" || c == "X" || c == "*" {
+			break
+		}
+		n, err := parseNumeric(c)
+		if err != nil {
+			return p, &ParseError{Input: s, Err: err}
+		}
+		nums = append(nums, n)
+	}
+	p.parts = len(nums)
+	for len(nums) < MaxComponents {
+		nums = append(nums, 0)
+	}
+
+	if suffix == "" {
+		p.v = Version{Major: nums[0], Minor: nums[1], Patch: nums[2], Revision: nums[3]}
+		return p, nil
+	}
+	if !p.full() {
+		return p, fmt.Errorf("%w: %q has a prerelease or build without a full version", ErrInvalidConstraint, s)
+	}
+	if p.v, err = ParseComponents(s, p.parts); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+// rangeTerm matches every version the partial describes
+func (p partial) rangeTerm() term {
+	lo := p.v
+	switch {
+	case p.parts == 0:
+		return term{}
+	case p.full():
+		return term{lo: &lo, loIncl: true, hi: &lo, hiIncl: true}
+	}
+	return term{lo: &lo, loIncl: true, hi: p.next()}
+}
+
+// next returns the lowest version above every version the partial describes
+func (p partial) next() *Version {
+	switch p.parts {
+	case 1:
+		return &Version{Major: p.v.Major + 1, PreRelease: "0"}
+	case 2:
+		return &Version{Major: p.v.Major, Minor: p.v.Minor + 1, PreRelease: "0"}
+	case 3:
+		return &Version{Major: p.v.Major, Minor: p.v.Minor, Patch: p.v.Patch + 1, PreRelease: "0"}
+	case 4:
+		return &Version{Major: p.v.Major, Minor: p.v.Minor, Patch: p.v.Patch, Revision: p.v.Revision + 1, PreRelease: "0"}
+	}
+	return nil
+}
+
+// full returns true if the partial has at least the three SemVer components;
+// a missing revision is 0
+func (p partial) full() bool {
+	return p.parts >= DefaultComponents
+}
+
+// caret returns the upper bound for ^, which allows changes that do not
+// modify the left-most non-zero component
+func (p partial) caret() *Version {
+	switch {
+	case p.v.Major > 0 || p.parts == 1:
+		return &Version{Major: p.v.Major + 1, PreRelease: "0"}
+	case p.v.Minor > 0 || p.parts == 2:
+		return &Version{Minor: p.v.Minor + 1, PreRelease: "0"}
+	}
+	return &Version{Patch: p.v.Patch + 1, PreRelease: "0"}
+}
+
+// tilde returns the upper bound for ~, which allows patch-level changes if a
+// minor version is specified and minor-level changes otherwise
+func (p partial) tilde() *Version {
+	if p.parts == 1 {
+		return &Version{Major: p.v.Major + 1, PreRelease: "0"}
+	}
+	return &Version{Major: p.v.Major, Minor: p.v.Minor + 1, PreRelease: "0"}
+}
+
+func (t term) check(v Version) bool {
+	in := true
+	if t.lo != nil {
+		c := Compare(v, *t.lo)
+		in = c > 0 || (c == 0 && t.loIncl)
+	}
+	if in && t.hi != nil {
+		c := Compare(v, *t.hi)
+		in = c < 0 || (c == 0 && t.hiIncl)
+	}
+	return in != t.negate
+}
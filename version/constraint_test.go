@@ -0,0 +1,128 @@
+package version
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConstraintCheck(t *testing.T) {
+	var tests = []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{">=1.2.0 <2.0.0", "1.2.0", true},
+		{">=1.2.0 <2.0.0", "1.9.9", true},
+		{">=1.2.0 <2.0.0", "2.0.0", false},
+		{">=1.2.0 <2.0.0", "1.1.9", false},
+		{">=1.2.0, <2.0.0", "1.5.0", true},
+		{">= 1.2.0 < 2.0.0", "1.5.0", true},
+		{"^1.4", "1.4.0", true},
+		{"^1.4", "1.9.0", true},
+		{"^1.4", "1.3.9", false},
+		{"^1.4", "2.0.0", false},
+		{"^1.4", "2.0.0-rc.1", false},
+		{"^0.4.2", "0.4.9", true},
+		{"^0.4.2", "0.5.0", false},
+		{"^0.0.3", "0.0.3", true},
+		{"^0.0.3", "0.0.4", false},
+		{"~1.4.2", "1.4.2", true},
+		{"~1.4.2", "1.4.9", true},
+		{"~1.4.2", "1.4.1", false},
+		{"~1.4.2", "1.5.0", false},
+		{"~1", "1.9.0", true},
+		{"~1", "2.0.0", false},
+		{"1.x", "1.0.0", true},
+		{"1.x", "1.99.3", true},
+		{"1.x", "2.0.0", false},
+		{"1.4.*", "1.4.7", true},
+		{"1.4", "1.5.0", false},
+		{"*", "0.0.1", true},
+		{"!=1.3.0", "1.3.0", false},
+		{"!=1.3.0", "1.3.1", true},
+		{"!=1.3", "1.3.1", false},
+		{"1.2.3", "1.2.3+build.1", true},
+		{"=1.2.3", "1.2.4", false},
+		{">1.2.3", "1.2.4", true},
+		{">1.2.3", "1.2.3", false},
+		{">1.2", "1.2.9", false},
+		{">1.2", "1.3.0", true},
+		{"<1.2", "1.2.0-rc.1", false},
+		{"<1.2", "1.1.9", true},
+		{"<=1.2", "1.2.9", true},
+		{"<=1.2", "1.3.0", false},
+		{"<=1.2.3", "1.2.3", true},
+		{">=1.3.0-rc.1", "1.3.0-rc.2", true},
+		{"^1.0 || ^3.0", "3.1.0", true},
+		{"^1.0 || ^3.0", "2.1.0", false},
+		{"1.2.3.4", "1.2.3.4", true},
+		{"1.2.3.4", "1.2.3.5", false},
+		{">1.2.3", "1.2.3.1", true},
+		{"~1.4", "1.4.2.7", true},
+	}
+	for _, test := range tests {
+		c, err := ParseConstraint(test.constraint)
+		if err != nil {
+			t.Errorf("ParseConstraint(%q) error = %v", test.constraint, err)
+			continue
+		}
+		v, err := FromString(test.version)
+		if err != nil {
+			v, err = ParseComponents(test.version, 4)
+		}
+		if err != nil {
+			t.Errorf("FromString(%q) error = %v", test.version, err)
+			continue
+		}
+		if got := c.Check(v); got != test.want {
+			t.Errorf("ParseConstraint(%q).Check(%v) = %v, want %v", test.constraint, v, got, test.want)
+		}
+	}
+}
+
+func TestParseConstraintErrors(t *testing.T) {
+	var tests = []struct {
+		constraint string
+		want       error
+	}{
+		{">=", ErrInvalidConstraint},
+		{">*", ErrInvalidConstraint},
+		{"^1.2-rc.1", ErrInvalidConstraint},
+		{"1.2.3.4.5", ErrComponentCount},
+		{"01.2", ErrLeadingZero},
+		{"^a.b", ErrInvalidNumber},
+	}
+	for _, test := range tests {
+		if _, err := ParseConstraint(test.constraint); !errors.Is(err, test.want) {
+			t.Errorf("ParseConstraint(%q) error = %v, want %v", test.constraint, err, test.want)
+		}
+	}
+}
+
+func TestListFilter(t *testing.T) {
+	versions := List{
+		{1, 3, 9, 0, "", ""},
+		{1, 4, 0, 0, "", ""},
+		{1, 4, 3, 0, "", ""},
+		{1, 5, 0, 0, "", ""},
+		{2, 0, 0, 0, "", ""},
+	}
+	want := List{
+		{1, 4, 0, 0, "", ""},
+		{1, 4, 3, 0, "", ""},
+	}
+
+	c, err := ParseConstraint("~1.4")
+	if err != nil {
+		t.Fatalf("ParseConstraint() error = %v", err)
+	}
+	got := versions.Filter(c)
+	if len(got) != len(want) {
+		t.Fatalf("Filter(%v) = %v, want %v", c, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Filter(%v)[%d] = %v, want %v", c, i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package version
+
+//go:generate go run github.com/abice/go-enum -f $GOFILE --marshal --names
+
+// Component ENUM(none, major, minor, patch, revision, prerelease, build)
+type Component string
+
+// Diff returns the most significant Component that differs between two
+// versions, or ComponentNone when they are identical
+func Diff(a, b Version) Component {
+	switch {
+	case a.Major != b.Major:
+		return ComponentMajor
+	case a.Minor != b.Minor:
+		return ComponentMinor
+	case a.Patch != b.Patch:
+		return ComponentPatch
+	case a.Revision != b.Revision:
+		return ComponentRevision
+	case a.PreRelease != b.PreRelease:
+		return ComponentPrerelease
+	case a.Build != b.Build:
+		return ComponentBuild
+	}
+	return ComponentNone
+}
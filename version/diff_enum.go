@@ -0,0 +1,93 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version:
+// Revision:
+// Build Date:
+// Built By:
+
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// ComponentNone is a Component of type none.
+	ComponentNone Component = "none"
+	// ComponentMajor is a Component of type major.
+	ComponentMajor Component = "major"
+	// ComponentMinor is a Component of type minor.
+	ComponentMinor Component = "minor"
+	// ComponentPatch is a Component of type patch.
+	ComponentPatch Component = "patch"
+	// ComponentRevision is a Component of type revision.
+	ComponentRevision Component = "revision"
+	// ComponentPrerelease is a Component of type prerelease.
+	ComponentPrerelease Component = "prerelease"
+	// ComponentBuild is a Component of type build.
+	ComponentBuild Component = "build"
+)
+
+var ErrInvalidComponent = fmt.Errorf("not a valid Component, try [%s]", strings.Join(_ComponentNames, ", "))
+
+var _ComponentNames = []string{
+	string(ComponentNone),
+	string(ComponentMajor),
+	string(ComponentMinor),
+	string(ComponentPatch),
+	string(ComponentRevision),
+	string(ComponentPrerelease),
+	string(ComponentBuild),
+}
+
+// ComponentNames returns a list of possible string values of Component.
+func ComponentNames() []string {
+	tmp := make([]string, len(_ComponentNames))
+	copy(tmp, _ComponentNames)
+	return tmp
+}
+
+// String implements the Stringer interface.
+func (x Component) String() string {
+	return string(x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x Component) IsValid() bool {
+	_, err := ParseComponent(string(x))
+	return err == nil
+}
+
+var _ComponentValue = map[string]Component{
+	"none":       ComponentNone,
+	"major":      ComponentMajor,
+	"minor":      ComponentMinor,
+	"patch":      ComponentPatch,
+	"revision":   ComponentRevision,
+	"prerelease": ComponentPrerelease,
+	"build":      ComponentBuild,
+}
+
+// ParseComponent attempts to convert a string to a Component.
+func ParseComponent(name string) (Component, error) {
+	if x, ok := _ComponentValue[name]; ok {
+		return x, nil
+	}
+	return Component(""), fmt.Errorf("%s is %w", name, ErrInvalidComponent)
+}
+
+// MarshalText implements the text marshaller method.
+func (x Component) MarshalText() ([]byte, error) {
+	return []byte(string(x)), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *Component) UnmarshalText(text []byte) error {
+	tmp, err := ParseComponent(string(text))
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
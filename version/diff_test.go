@@ -0,0 +1,34 @@
+package version
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	var tests = []struct {
+		a, b string
+		want Component
+	}{
+		{"1.2.3", "1.2.3", ComponentNone},
+		{"1.2.3", "2.2.3", ComponentMajor},
+		{"1.2.3", "1.3.0", ComponentMinor},
+		{"1.2.3", "1.2.4-rc.1", ComponentPatch},
+		{"1.2.3.1", "1.2.3.2", ComponentRevision},
+		{"1.2.3-rc.1", "1.2.3", ComponentPrerelease},
+		{"1.2.3+build.1", "1.2.3+build.2", ComponentBuild},
+	}
+	for _, test := range tests {
+		a, err := Parse(test.a)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", test.a, err)
+		}
+		b, err := Parse(test.b)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", test.b, err)
+		}
+		if got := Diff(a, b); got != test.want {
+			t.Errorf("Diff(%q, %q) = %v, want %v", test.a, test.b, got, test.want)
+		}
+		if got := Diff(b, a); got != test.want {
+			t.Errorf("Diff(%q, %q) = %v, want %v", test.b, test.a, got, test.want)
+		}
+	}
+}
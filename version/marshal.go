@@ -0,0 +1,135 @@
+package version
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Structured is a Version that is marshaled to JSON as an object with
+// major, minor, patch, revision, prerelease and build fields instead of a
+// string, e.g. json.Marshal(version.Structured(v))
+type Structured Version
+
+// structuredJSON is the JSON object form of a Version
+type structuredJSON struct {
+	Major      int    `json:"major"`
+	Minor      int    `json:"minor"`
+	Patch      int    `json:"patch"`
+	Revision   int    `json:"revision,omitempty"`
+	PreRelease string `json:"prerelease,omitempty"`
+	Build      string `json:"build,omitempty"`
+}
+
+var (
+	_ encoding.TextMarshaler   = Version{}
+	_ encoding.TextUnmarshaler = &Version{}
+	_ json.Marshaler           = Version{}
+	_ json.Unmarshaler         = &Version{}
+	_ json.Marshaler           = Structured{}
+	_ json.Unmarshaler         = &Structured{}
+	_ sql.Scanner              = &Version{}
+	_ driver.Valuer            = Version{}
+)
+
+// Parse returns a Version based on a SemVer string with two to four
+// components, such as the ones written by Version.String
+func Parse(s string) (Version, error) {
+	core := s
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		core = s[:i]
+	}
+	n := strings.Count(core, ".") + 1
+	if n < MinComponents || n > MaxComponents {
+		n = DefaultComponents
+	}
+	return ParseComponents(s, n)
+}
+
+// MarshalText implements encoding.TextMarshaler; YAML and other text based
+// encoders use it to write the Version as a string
+func (v Version) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (v *Version) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, writing the Version as a string
+func (v Version) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Both the string form and the
+// object form written by Structured are accepted.
+func (v *Version) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil
+	case bytes.HasPrefix(data, []byte("{")):
+		return (*Structured)(v).UnmarshalJSON(data)
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("version must be a JSON string or object: %w", err)
+	}
+	return v.UnmarshalText([]byte(s))
+}
+
+// MarshalJSON implements json.Marshaler, writing the Version as an object
+func (s Structured) MarshalJSON() ([]byte, error) {
+	return json.Marshal(structuredJSON(s))
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The object is validated like
+// a parsed Version, and the string form is accepted as well.
+func (s *Structured) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte("{")) {
+		return (*Version)(s).UnmarshalJSON(data)
+	}
+
+	var obj structuredJSON
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	v := Version(obj)
+	if _, err := Parse(v.String()); err != nil {
+		return err
+	}
+	*s = Structured(v)
+	return nil
+}
+
+// Scan implements sql.Scanner for string and []byte columns; NULL is
+// scanned as the zero Version
+func (v *Version) Scan(src any) error {
+	switch s := src.(type) {
+	case nil:
+		*v = Version{}
+		return nil
+	case string:
+		return v.UnmarshalText([]byte(s))
+	case []byte:
+		return v.UnmarshalText(s)
+	}
+	return fmt.Errorf("cannot scan %T into a Version", src)
+}
+
+// Value implements driver.Valuer, storing the Version as a string
+func (v Version) Value() (driver.Value, error) {
+	return v.String(), nil
+}
@@ -0,0 +1,161 @@
+package version
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  Version
+	}{
+		{"1.2", Version{1, 2, 0, 0, "", ""}},
+		{"1.2.3", Version{1, 2, 3, 0, "", ""}},
+		{"1.2.3.4-rc.1+build.5", Version{1, 2, 3, 4, "rc.1", "build.5"}},
+	}
+	for _, test := range tests {
+		v, err := Parse(test.input)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", test.input, err)
+			continue
+		}
+		if v != test.want {
+			t.Errorf("Parse(%q) = %#v, want %#v", test.input, v, test.want)
+		}
+	}
+	for _, input := range []string{"", "1", "1.2.3.4.5", "1.2.x"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) should fail", input)
+		}
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	type manifest struct {
+		Version Version  `json:"version"`
+		Latest  *Version `json:"latest,omitempty"`
+	}
+	in := manifest{Version: Version{1, 2, 3, 4, "rc.1", "build.5"}}
+
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := `{"version":"1.2.3.4-rc.1+build.5"}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var out manifest
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal(%s) error = %v", data, err)
+	}
+	if out != in {
+		t.Errorf("Unmarshal(%s) = %#v, want %#v", data, out, in)
+	}
+}
+
+func TestStructuredJSON(t *testing.T) {
+	v := Version{1, 2, 3, 0, "rc.1", ""}
+	data, err := json.Marshal(Structured(v))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := `{"major":1,"minor":2,"patch":3,"prerelease":"rc.1"}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var tests = []struct {
+		input string
+		want  Version
+	}{
+		{`{"major":1,"minor":2,"patch":3,"prerelease":"rc.1"}`, v},
+		{`"1.2.3-rc.1"`, v},
+		{`{"major":1,"minor":2,"patch":3,"revision":4,"build":"abc"}`, Version{1, 2, 3, 4, "", "abc"}},
+	}
+	for _, test := range tests {
+		var s Structured
+		if err := json.Unmarshal([]byte(test.input), &s); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", test.input, err)
+			continue
+		}
+		if Version(s) != test.want {
+			t.Errorf("Unmarshal(%s) = %#v, want %#v", test.input, s, test.want)
+		}
+
+		var got Version
+		if err := json.Unmarshal([]byte(test.input), &got); err != nil {
+			t.Errorf("Unmarshal(%s) into Version error = %v", test.input, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Unmarshal(%s) into Version = %#v, want %#v", test.input, got, test.want)
+		}
+	}
+
+	var s Structured
+	if err := json.Unmarshal([]byte(`{"major":1,"prerelease":"01"}`), &s); !errors.Is(err, ErrLeadingZero) {
+		t.Errorf("Unmarshal() error = %v, want %v", err, ErrLeadingZero)
+	}
+	if err := json.Unmarshal([]byte(`1.2`), &s); err == nil {
+		t.Errorf("Unmarshal(1.2) should fail")
+	}
+}
+
+func TestMarshalText(t *testing.T) {
+	v := Version{1, 2, 3, 0, "", "build.5"}
+	text, err := v.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText() error = %v", err)
+	}
+	if want := "1.2.3+build.5"; string(text) != want {
+		t.Errorf("MarshalText() = %q, want %q", text, want)
+	}
+
+	var got Version
+	if err := got.UnmarshalText(text); err != nil {
+		t.Fatalf("UnmarshalText(%q) error = %v", text, err)
+	}
+	if got != v {
+		t.Errorf("UnmarshalText(%q) = %#v, want %#v", text, got, v)
+	}
+	if err := got.UnmarshalText([]byte("latest")); err == nil {
+		t.Errorf("UnmarshalText(latest) should fail")
+	}
+}
+
+func TestSQL(t *testing.T) {
+	v := Version{1, 2, 3, 0, "rc.1", ""}
+	value, err := v.Value()
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+	if value != "1.2.3-rc.1" {
+		t.Errorf("Value() = %v, want %q", value, "1.2.3-rc.1")
+	}
+
+	var tests = []struct {
+		src  any
+		want Version
+	}{
+		{"1.2.3-rc.1", v},
+		{[]byte("1.2.3-rc.1"), v},
+		{nil, Version{}},
+	}
+	for _, test := range tests {
+		got := Version{9, 9, 9, 0, "", ""}
+		if err := got.Scan(test.src); err != nil {
+			t.Errorf("Scan(%#v) error = %v", test.src, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Scan(%#v) = %#v, want %#v", test.src, got, test.want)
+		}
+	}
+
+	var got Version
+	if err := got.Scan(int64(1)); err == nil {
+		t.Errorf("Scan(1) should fail")
+	}
+}
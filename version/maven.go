@@ -0,0 +1,294 @@
+package version
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+)
+
+// Maven is a Scheme for versions of JVM projects, e.g. 1.2.3-SNAPSHOT,
+// 1.2.3-alpha-1 or 1.2.3-RC2. The qualifier following the first "-" is
+// stored in PreRelease as written, and versions are ordered according to
+// Maven's ComparableVersion rules, see
+// https://maven.apache.org/pom.html#version-order-specification
+//
+// Versions have up to Components (3 unless set) numbers; missing numbers
+// are 0 and Format always writes Components numbers.
+type Maven struct {
+	Components int
+}
+
+// SnapshotQualifier is the qualifier of Maven development versions
+const SnapshotQualifier = "SNAPSHOT"
+
+// mavenQualifiers are the well-known qualifiers in ascending order; the
+// empty string is the release. Unknown qualifiers sort after all of them.
+var mavenQualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+// mavenAliases are alternative spellings of the well-known qualifiers
+var mavenAliases = map[string]string{
+	"ga":      "",
+	"final":   "",
+	"release": "",
+	"cr":      "rc",
+}
+
+var _ Scheme = Maven{}
+
+// Name implements Scheme.Name()
+func (Maven) Name() string {
+	return "maven"
+}
+
+// Parse implements Scheme.Parse()
+func (m Maven) Parse(s string) (ver Version, err error) {
+	core, qualifier, hasQualifier := strings.Cut(s, "-")
+	numbers := strings.Split(core, ".")
+	if n := components(m.Components); len(numbers) > n {
+		return ver, &ParseError{Input: s, Err: fmt.Errorf("%w: expected at most %s", ErrComponentCount, componentLayout(n))}
+	}
+
+	nums := make([]int, MaxComponents)
+	for i, c := range numbers {
+		if nums[i], err = parseNumeric(c); err != nil {
+			return ver, &ParseError{Input: s, Err: err}
+		}
+	}
+
+	if hasQualifier {
+		if qualifier == "" {
+			return ver, &ParseError{Input: s, Err: fmt.Errorf("qualifier: %w", ErrEmptyIdentifier)}
+		}
+		for _, r := range qualifier {
+			switch {
+			case r >= '0' && r <= '9', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-', r == '.', r == '_':
+			default:
+				return ver, &ParseError{Input: s, Err: fmt.Errorf("qualifier: %w %q", ErrInvalidCharacter, r)}
+			}
+		}
+	}
+
+	return Version{Major: nums[0], Minor: nums[1], Patch: nums[2], Revision: nums[3], PreRelease: qualifier}, nil
+}
+
+// Format implements Scheme.Format()
+func (m Maven) Format(v Version) string {
+	s := v.formatComponents(components(m.Components))
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	return s
+}
+
+// Compare implements Scheme.Compare()
+func (m Maven) Compare(a, b Version) int {
+	return parseMavenItems(m.Format(a)).compare(parseMavenItems(m.Format(b)))
+}
+
+// Bump implements Scheme.Bump(). The major, minor, patch and revision
+// fields drop the qualifier. The snapshot field returns the SNAPSHOT of the
+// next version (bumping the last component) after a release, or of the same
+// version for any other qualifier.
+func (m Maven) Bump(v Version, field string) (Version, error) {
+	n := components(m.Components)
+	if field != "snapshot" {
+		return bumpComponents(v, field, n)
+	}
+	if v.PreRelease == "" {
+		var err error
+		if v, err = bumpComponents(v, componentFields[n-1], n); err != nil {
+			return v, err
+		}
+	}
+	v.PreRelease = SnapshotQualifier
+	return v, nil
+}
+
+type (
+	// mavenItem is an element of a parsed Maven version; a nil mavenItem is
+	// the implicit item used to compare lists of different lengths
+	mavenItem interface {
+		compare(other mavenItem) int
+		isNull() bool
+	}
+	// mavenInt is a number without leading zeros, so zero is ""
+	mavenInt    string
+	mavenString string
+	mavenList   []mavenItem
+)
+
+// parseMavenItems splits a version into items the way ComparableVersion does:
+// "." separates items, "-" and transitions between digits and letters start
+// a new sub-list
+func parseMavenItems(version string) *mavenList {
+	version = strings.ToLower(version)
+	root := &mavenList{}
+	list := root
+	stack := []*mavenList{root}
+
+	push := func() {
+		sub := &mavenList{}
+		*list = append(*list, sub)
+		list = sub
+		stack = append(stack, sub)
+	}
+
+	isDigit := false
+	start := 0
+	for i := 0; i < len(version); i++ {
+		c := version[i]
+		switch {
+		case c == '.' || c == '-':
+			if i == start {
+				*list = append(*list, mavenInt(""))
+			} else {
+				*list = append(*list, parseMavenItem(isDigit, version[start:i], false))
+			}
+			start = i + 1
+			if c == '-' {
+				push()
+			}
+		case c >= '0' && c <= '9':
+			if !isDigit && i > start {
+				*list = append(*list, parseMavenItem(false, version[start:i], true))
+				start = i
+				push()
+			}
+			isDigit = true
+		default:
+			if isDigit && i > start {
+				*list = append(*list, parseMavenItem(true, version[start:i], false))
+				start = i
+				push()
+			}
+			isDigit = false
+		}
+	}
+	if len(version) > start {
+		*list = append(*list, parseMavenItem(isDigit, version[start:], false))
+	}
+
+	for i := len(stack) - 1; i >= 0; i-- {
+		stack[i].normalize()
+	}
+	return root
+}
+
+func parseMavenItem(isDigit bool, s string, followedByDigit bool) mavenItem {
+	if isDigit {
+		return mavenInt(strings.TrimLeft(s, "0"))
+	}
+	if followedByDigit && len(s) == 1 {
+		switch s {
+		case "a":
+			s = "alpha"
+		case "b":
+			s = "beta"
+		case "m":
+			s = "milestone"
+		}
+	}
+	if alias, ok := mavenAliases[s]; ok {
+		s = alias
+	}
+	return mavenString(s)
+}
+
+// normalize removes trailing null items (0, "" and empty lists) up to the last sub-list
+func (l *mavenList) normalize() {
+	for i := len(*l) - 1; i >= 0; i-- {
+		item := (*l)[i]
+		if item.isNull() {
+			*l = append((*l)[:i], (*l)[i+1:]...)
+		} else if _, ok := item.(*mavenList); !ok {
+			break
+		}
+	}
+}
+
+func (i mavenInt) isNull() bool {
+	return i == ""
+}
+
+func (i mavenInt) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		if i.isNull() {
+			return 0
+		}
+		return 1
+	case mavenInt:
+		// Leading zeros are stripped, so the longer number is larger
+		if c := cmp.Compare(len(i), len(o)); c != 0 {
+			return c
+		}
+		return strings.Compare(string(i), string(o))
+	}
+	return 1
+}
+
+func (s mavenString) isNull() bool {
+	return s == ""
+}
+
+// comparable returns a key ordering well-known qualifiers by their position
+// and unknown ones lexically after them
+func (s mavenString) comparable() string {
+	for i, q := range mavenQualifiers {
+		if string(s) == q {
+			return fmt.Sprint(i)
+		}
+	}
+	return fmt.Sprintf("%d-%s", len(mavenQualifiers), s)
+}
+
+func (s mavenString) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		return strings.Compare(s.comparable(), mavenString("").comparable())
+	case mavenString:
+		return strings.Compare(s.comparable(), o.comparable())
+	}
+	return -1
+}
+
+func (l *mavenList) isNull() bool {
+	return len(*l) == 0
+}
+
+func (l *mavenList) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		if len(*l) == 0 {
+			return 0
+		}
+		return (*l)[0].compare(nil)
+	case mavenInt:
+		return -1
+	case mavenString:
+		return 1
+	case *mavenList:
+		for i := 0; i < len(*l) || i < len(*o); i++ {
+			var left, right mavenItem
+			if i < len(*l) {
+				left = (*l)[i]
+			}
+			if i < len(*o) {
+				right = (*o)[i]
+			}
+
+			var c int
+			if left == nil {
+				if right != nil {
+					c = -right.compare(nil)
+				}
+			} else {
+				c = left.compare(right)
+			}
+			if c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,122 @@
+package version
+
+import (
+	"testing"
+)
+
+func testMavenOrder(t *testing.T, ordered []string) {
+	t.Helper()
+	for i := range ordered {
+		for j := range ordered {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			got := parseMavenItems(ordered[i]).compare(parseMavenItems(ordered[j]))
+			if got != want {
+				t.Errorf("compare(%q, %q) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+}
+
+func TestMavenQualifierOrder(t *testing.T) {
+	// From Maven's ComparableVersionTest
+	testMavenOrder(t, []string{
+		"1-alpha2snapshot", "1-alpha2", "1-alpha-123", "1-beta-2", "1-beta123", "1-m2", "1-m11", "1-rc", "1-cr2",
+		"1-rc123", "1-SNAPSHOT", "1", "1-sp", "1-sp2", "1-sp123", "1-abc", "1-def", "1-pom-1", "1-1-snapshot",
+		"1-1", "1-2", "1-123",
+	})
+}
+
+func TestMavenNumberOrder(t *testing.T) {
+	// From Maven's ComparableVersionTest
+	testMavenOrder(t, []string{
+		"2.0", "2-1", "2.0.2", "2.0.123", "2.1.0", "2.1-a", "2.1b", "2.1-c", "2.1-1", "2.1.0.1", "2.2",
+		"2.123", "11.a2", "11.a11", "11.b2", "11.b11", "11.m2", "11.m11", "11", "11.a", "11b", "11c", "11m",
+	})
+}
+
+func TestMavenEquivalents(t *testing.T) {
+	for _, pair := range [][2]string{
+		{"1", "1.0.0"},
+		{"1-ga", "1"},
+		{"1.2.3-Final", "1.2.3"},
+		{"1.2.3-RELEASE", "1.2.3"},
+		{"1-cr1", "1-rc1"},
+		{"1a1", "1-alpha-1"},
+		{"1.0.0-SNAPSHOT", "1-snapshot"},
+	} {
+		if got := parseMavenItems(pair[0]).compare(parseMavenItems(pair[1])); got != 0 {
+			t.Errorf("compare(%q, %q) = %d, want 0", pair[0], pair[1], got)
+		}
+	}
+}
+
+func TestMavenScheme(t *testing.T) {
+	m := Maven{}
+	ordered := []string{"1.2.3-alpha-1", "1.2.3-RC2", "1.2.3-SNAPSHOT", "1.2.3", "1.2.3-sp1", "1.2.4-SNAPSHOT"}
+	versions := List{}
+	for _, s := range ordered {
+		v, err := m.Parse(s)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", s, err)
+		}
+		if got := m.Format(v); got != s {
+			t.Errorf("Format(Parse(%q)) = %q", s, got)
+		}
+		versions = append(versions, v)
+	}
+	for i := 1; i < len(versions); i++ {
+		if m.Compare(versions[i-1], versions[i]) >= 0 {
+			t.Errorf("Compare(%q, %q) should be < 0", ordered[i-1], ordered[i])
+		}
+	}
+
+	for _, s := range []string{"1.2.3.4", "1.2.3-", "01.2.3", "1.2.3-foo+bar"} {
+		if _, err := m.Parse(s); err == nil {
+			t.Errorf("Parse(%q) should fail", s)
+		}
+	}
+}
+
+func TestMavenBumpSnapshot(t *testing.T) {
+	var tests = []struct {
+		input string
+		field string
+		want  string
+	}{
+		{"1.2.3", "snapshot", "1.2.4-SNAPSHOT"},
+		{"1.3.0-RC2", "snapshot", "1.3.0-SNAPSHOT"},
+		{"1.3.0-RC2", "minor", "1.4.0"},
+		{"1.2.3-SNAPSHOT", "patch", "1.2.4"},
+	}
+	m := Maven{}
+	for _, test := range tests {
+		v, _ := m.Parse(test.input)
+		bumped, err := m.Bump(v, test.field)
+		if err != nil {
+			t.Errorf("Bump(%q, %q) error = %v", test.input, test.field, err)
+		}
+		if got := m.Format(bumped); got != test.want {
+			t.Errorf("Bump(%q, %q) = %q, want %q", test.input, test.field, got, test.want)
+		}
+	}
+}
+
+func TestMavenBumpSnapshotComponents(t *testing.T) {
+	m := Maven{Components: 2}
+	v, err := m.Parse("1.4")
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", "1.4", err)
+	}
+	bumped, err := m.Bump(v, "snapshot")
+	if err != nil {
+		t.Fatalf("Bump(%q) error = %v", "snapshot", err)
+	}
+	if got := m.Format(bumped); got != "1.5-SNAPSHOT" {
+		t.Errorf("Bump(%q, %q) = %q, want %q", "1.4", "snapshot", got, "1.5-SNAPSHOT")
+	}
+}
@@ -0,0 +1,320 @@
+package version
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PEP440 is the https://peps.python.org/pep-0440/ Scheme used by Python
+// packages, e.g. 1.2.3rc1, 1.2.3.post1 or 1.2.3.dev4.
+//
+// The pre-, post- and development release segments are stored in
+// PreRelease as dot-separated identifiers (e.g. "rc.1.post.2.dev.3") and the
+// local version label in Build. Parsing accepts the alternative spellings
+// allowed by the PEP and Format writes the normalized form. Format also
+// accepts SemVer versions with prereleases such as "alpha.1" or "rc.2", so
+// SemVer tags can be written in PEP 440 form.
+//
+// Release numbers have up to Components (3 unless set) numbers; missing
+// numbers are 0 and Format always writes Components numbers.
+type PEP440 struct {
+	Components int
+}
+
+// pep440Release holds the segments of a PEP 440 version that follow the release number
+type pep440Release struct {
+	preL       string
+	preN       int
+	post, dev  int
+	hasPost    bool
+	hasDev     bool
+	unknownPre string
+}
+
+var (
+	_ Scheme = PEP440{}
+
+	// ErrEpoch is returned for PEP 440 versions with a non-zero epoch, which are not supported
+	ErrEpoch = errors.New("version epochs are not supported")
+
+	pep440Pattern = regexp.MustCompile(`(?i)^v?` +
+		`(?:(?P<epoch>[0-9]+)!)?` +
+		`(?P<release>[0-9]+(?:\.[0-9]+)*)` +
+		`(?:[-_.]?(?P<pre_l>alpha|a|beta|b|preview|pre|c|rc)[-_.]?(?P<pre_n>[0-9]+)?)?` +
+		`(?:-(?P<post_n1>[0-9]+)|[-_.]?(?P<post_l>post|rev|r)[-_.]?(?P<post_n2>[0-9]+)?)?` +
+		`(?:[-_.]?(?P<dev_l>dev)[-_.]?(?P<dev_n>[0-9]+)?)?` +
+		`(?:\+(?P<local>[a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+	pep440Identifier = regexp.MustCompile(`^([a-z]+)([0-9]*)$`)
+)
+
+// Name implements Scheme.Name()
+func (PEP440) Name() string {
+	return "pep440"
+}
+
+// Parse implements Scheme.Parse()
+func (p PEP440) Parse(s string) (ver Version, err error) {
+	m := pep440Pattern.FindStringSubmatch(s)
+	if m == nil {
+		return ver, &ParseError{Input: s, Err: errors.New("not a PEP 440 version")}
+	}
+	group := func(name string) string {
+		return m[pep440Pattern.SubexpIndex(name)]
+	}
+	number := func(name string) int {
+		// The pattern only matches digits; overflows are the only possible error
+		n, _ := strconv.Atoi(group(name))
+		return n
+	}
+
+	if epoch := group("epoch"); epoch != "" && number("epoch") != 0 {
+		return ver, &ParseError{Input: s, Err: ErrEpoch}
+	}
+
+	release := strings.Split(group("release"), ".")
+	if n := components(p.Components); len(release) > n {
+		return ver, &ParseError{Input: s, Err: fmt.Errorf("%w: expected at most %s", ErrComponentCount, componentLayout(n))}
+	}
+	nums := make([]int, MaxComponents)
+	for i, c := range release {
+		if nums[i], err = strconv.Atoi(c); err != nil {
+			return ver, &ParseError{Input: s, Err: fmt.Errorf("%w: %q", ErrInvalidNumber, c)}
+		}
+	}
+
+	var r pep440Release
+	if l := group("pre_l"); l != "" {
+		r.preL = normalizePreLabel(l)
+		r.preN = number("pre_n")
+	}
+	if group("post_n1") != "" {
+		r.hasPost, r.post = true, number("post_n1")
+	} else if group("post_l") != "" {
+		r.hasPost, r.post = true, number("post_n2")
+	}
+	if group("dev_l") != "" {
+		r.hasDev, r.dev = true, number("dev_n")
+	}
+
+	return Version{
+		Major:      nums[0],
+		Minor:      nums[1],
+		Patch:      nums[2],
+		Revision:   nums[3],
+		PreRelease: r.identifiers(),
+		Build:      normalizeLocal(group("local")),
+	}, nil
+}
+
+// Format implements Scheme.Format()
+func (p PEP440) Format(v Version) string {
+	r := parsePEP440Release(v.PreRelease)
+
+	var sb strings.Builder
+	sb.WriteString(v.formatComponents(components(p.Components)))
+	if r.preL != "" {
+		fmt.Fprintf(&sb, "%s%d", r.preL, r.preN)
+	}
+	if r.hasPost {
+		fmt.Fprintf(&sb, ".post%d", r.post)
+	}
+	if r.hasDev {
+		fmt.Fprintf(&sb, ".dev%d", r.dev)
+	}
+
+	// Prereleases that have no PEP 440 equivalent (e.g. a commit SHA) become
+	// part of the local version label
+	local := normalizeLocal(v.Build)
+	if r.unknownPre != "" {
+		local = strings.Trim(normalizeLocal(r.unknownPre)+"."+local, ".")
+	}
+	if local != "" {
+		sb.WriteString("+" + local)
+	}
+	return sb.String()
+}
+
+// Compare implements Scheme.Compare() with the ordering from
+// https://peps.python.org/pep-0440/#summary-of-permitted-suffixes-and-relative-ordering
+func (PEP440) Compare(a, b Version) int {
+	if c := cmp.Compare(a.Major, b.Major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Minor, b.Minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Patch, b.Patch); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Revision, b.Revision); c != 0 {
+		return c
+	}
+
+	ra, rb := parsePEP440Release(a.PreRelease), parsePEP440Release(b.PreRelease)
+	if c := cmp.Compare(ra.preKey(), rb.preKey()); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(ra.preN, rb.preN); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(ra.postKey(), rb.postKey()); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(ra.devKey(), rb.devKey()); c != 0 {
+		return c
+	}
+	return compareLocal(normalizeLocal(a.Build), normalizeLocal(b.Build))
+}
+
+// Bump implements Scheme.Bump() for the major, minor, patch and revision
+// fields. The pre-, post- and development release segments and local
+// version label are dropped from the result.
+func (p PEP440) Bump(v Version, field string) (Version, error) {
+	return bumpComponents(v, field, components(p.Components))
+}
+
+// identifiers encodes the release segments as PreRelease identifiers
+func (r pep440Release) identifiers() string {
+	var ids []string
+	if r.preL != "" {
+		ids = append(ids, r.preL, strconv.Itoa(r.preN))
+	}
+	if r.hasPost {
+		ids = append(ids, "post", strconv.Itoa(r.post))
+	}
+	if r.hasDev {
+		ids = append(ids, "dev", strconv.Itoa(r.dev))
+	}
+	return strings.Join(ids, ".")
+}
+
+// preKey orders development releases of a final release before its
+// prereleases, and prereleases before the final release
+func (r pep440Release) preKey() int {
+	switch {
+	case r.preL == "" && !r.hasPost && r.hasDev:
+		return -1
+	case r.preL == "a":
+		return 0
+	case r.preL == "b":
+		return 1
+	case r.preL == "rc":
+		return 2
+	}
+	return 3
+}
+
+// postKey orders versions without a post release before those with one
+func (r pep440Release) postKey() int {
+	if !r.hasPost {
+		return -1
+	}
+	return r.post
+}
+
+// devKey orders development releases before versions without one
+func (r pep440Release) devKey() int {
+	if !r.hasDev {
+		return math.MaxInt
+	}
+	return r.dev
+}
+
+// parsePEP440Release reads the release segments from a PreRelease. Besides
+// the identifiers written by PEP440.Parse, it accepts common SemVer spellings
+// such as "alpha.1", "beta", "rc1" or "dev.4". Anything else is kept in
+// unknownPre.
+func parsePEP440Release(pre string) (r pep440Release) {
+	if pre == "" {
+		return r
+	}
+
+	ids := strings.FieldsFunc(strings.ToLower(pre), func(c rune) bool {
+		return c == '.' || c == '-' || c == '_'
+	})
+	seen := 0
+	for i := 0; i < len(ids); i++ {
+		m := pep440Identifier.FindStringSubmatch(ids[i])
+		if m == nil {
+			return pep440Release{unknownPre: pre}
+		}
+		label, num := m[1], m[2]
+		if num == "" && i+1 < len(ids) && isNumeric(ids[i+1]) {
+			i++
+			num = ids[i]
+		}
+		n, err := strconv.Atoi(cmp.Or(num, "0"))
+		if err != nil {
+			return pep440Release{unknownPre: pre}
+		}
+
+		// Segments must appear in the order pre, post, dev and only once
+		var order int
+		switch label {
+		case "a", "alpha", "b", "beta", "c", "rc", "pre", "preview":
+			order = 1
+			r.preL, r.preN = normalizePreLabel(label), n
+		case "post", "rev", "r":
+			order = 2
+			r.hasPost, r.post = true, n
+		case "dev":
+			order = 3
+			r.hasDev, r.dev = true, n
+		default:
+			return pep440Release{unknownPre: pre}
+		}
+		if order <= seen {
+			return pep440Release{unknownPre: pre}
+		}
+		seen = order
+	}
+	return r
+}
+
+// normalizePreLabel returns the normalized spelling of a prerelease label
+func normalizePreLabel(l string) string {
+	switch strings.ToLower(l) {
+	case "a", "alpha":
+		return "a"
+	case "b", "beta":
+		return "b"
+	}
+	return "rc"
+}
+
+// normalizeLocal lowercases a local version label and separates its segments with dots
+func normalizeLocal(l string) string {
+	return strings.NewReplacer("-", ".", "_", ".").Replace(strings.ToLower(l))
+}
+
+// compareLocal compares local version labels; numeric segments are
+// greater than alphanumeric ones and a version without a label is lowest
+func compareLocal(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return -1
+	case b == "":
+		return 1
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareIdentifier(as[i], bs[i]); c != 0 {
+			// compareIdentifier orders numeric identifiers first, PEP 440 the opposite
+			an, bn := isNumeric(as[i]), isNumeric(bs[i])
+			if an != bn {
+				return -c
+			}
+			return c
+		}
+	}
+	return cmp.Compare(len(as), len(bs))
+}
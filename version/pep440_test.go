@@ -0,0 +1,137 @@
+package version
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPEP440Parse(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  Version
+		norm  string
+	}{
+		{"1.2.3", Version{1, 2, 3, 0, "", ""}, "1.2.3"},
+		{"1.2", Version{1, 2, 0, 0, "", ""}, "1.2.0"},
+		{"1.2.3rc1", Version{1, 2, 3, 0, "rc.1", ""}, "1.2.3rc1"},
+		{"1.2.3a1", Version{1, 2, 3, 0, "a.1", ""}, "1.2.3a1"},
+		{"1.2.3-alpha.2", Version{1, 2, 3, 0, "a.2", ""}, "1.2.3a2"},
+		{"1.2.3.beta", Version{1, 2, 3, 0, "b.0", ""}, "1.2.3b0"},
+		{"1.2.3c1", Version{1, 2, 3, 0, "rc.1", ""}, "1.2.3rc1"},
+		{"1.2.3.dev4", Version{1, 2, 3, 0, "dev.4", ""}, "1.2.3.dev4"},
+		{"1.2.3-dev", Version{1, 2, 3, 0, "dev.0", ""}, "1.2.3.dev0"},
+		{"1.2.3.post1", Version{1, 2, 3, 0, "post.1", ""}, "1.2.3.post1"},
+		{"1.2.3-1", Version{1, 2, 3, 0, "post.1", ""}, "1.2.3.post1"},
+		{"1.2.3.rev2", Version{1, 2, 3, 0, "post.2", ""}, "1.2.3.post2"},
+		{"1.2.3rc1.post2.dev3", Version{1, 2, 3, 0, "rc.1.post.2.dev.3", ""}, "1.2.3rc1.post2.dev3"},
+		{"1.2.3+ubuntu-1", Version{1, 2, 3, 0, "", "ubuntu.1"}, "1.2.3+ubuntu.1"},
+		{"0!1.2.3", Version{1, 2, 3, 0, "", ""}, "1.2.3"},
+		{"V1.2.3RC1", Version{1, 2, 3, 0, "rc.1", ""}, "1.2.3rc1"},
+	}
+	s := PEP440{}
+	for _, test := range tests {
+		v, err := s.Parse(test.input)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", test.input, err)
+			continue
+		}
+		if v != test.want {
+			t.Errorf("Parse(%q) = %#v, want %#v", test.input, v, test.want)
+		}
+		if got := s.Format(v); got != test.norm {
+			t.Errorf("Format(Parse(%q)) = %q, want %q", test.input, got, test.norm)
+		}
+	}
+}
+
+func TestPEP440ParseErrors(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  error
+	}{
+		{"1!1.2.3", ErrEpoch},
+		{"1.2.3.4", ErrComponentCount},
+	}
+	s := PEP440{}
+	for _, test := range tests {
+		if _, err := s.Parse(test.input); !errors.Is(err, test.want) {
+			t.Errorf("Parse(%q) error = %v, want %v", test.input, err, test.want)
+		}
+	}
+	for _, input := range []string{"", "1.2.3-", "1.2.3foo", "1.2.3.dev1.post1", "latest"} {
+		if _, err := s.Parse(input); err == nil {
+			t.Errorf("Parse(%q) should fail", input)
+		}
+	}
+}
+
+func TestPEP440Compare(t *testing.T) {
+	// In ascending order, per https://peps.python.org/pep-0440/#summary-of-permitted-suffixes-and-relative-ordering
+	ordered := []string{
+		"1.0.dev456",
+		"1.0a1",
+		"1.0a2.dev456",
+		"1.0a12.dev456",
+		"1.0a12",
+		"1.0b1.dev456",
+		"1.0b2",
+		"1.0b2.post345.dev456",
+		"1.0b2.post345",
+		"1.0rc1.dev456",
+		"1.0rc1",
+		"1.0",
+		"1.0+abc.5",
+		"1.0+abc.7",
+		"1.0+5",
+		"1.0.post456.dev34",
+		"1.0.post456",
+		"1.0.15",
+		"1.1.dev1",
+	}
+	s := PEP440{}
+	for i := range ordered {
+		for j := range ordered {
+			a, err := s.Parse(ordered[i])
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", ordered[i], err)
+			}
+			b, _ := s.Parse(ordered[j])
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := s.Compare(a, b); got != want {
+				t.Errorf("Compare(%q, %q) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+}
+
+func TestPEP440FormatSemVer(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{"1.2.3", "1.2.3"},
+		{"1.2.3-rc.1", "1.2.3rc1"},
+		{"1.2.3-rc1", "1.2.3rc1"},
+		{"1.2.3-rc-2", "1.2.3rc2"},
+		{"1.2.3-alpha.1", "1.2.3a1"},
+		{"1.2.3-beta", "1.2.3b0"},
+		{"1.2.3-dev.4", "1.2.3.dev4"},
+		{"1.2.3-rc.1+build.5", "1.2.3rc1+build.5"},
+		{"1.2.3-9d8ceaa", "1.2.3+9d8ceaa"},
+		{"1.2.3-9d8ceaa+build.5", "1.2.3+9d8ceaa.build.5"},
+	}
+	for _, test := range tests {
+		v, err := FromString(test.input)
+		if err != nil {
+			t.Fatalf("FromString(%q) error = %v", test.input, err)
+		}
+		if got := (PEP440{}).Format(v); got != test.want {
+			t.Errorf("PEP440{}.Format(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// A Scheme parses, formats, orders and bumps versions of a particular format.
+// Every scheme represents its versions with the Version struct, but is free
+// to decide what the fields mean and how they are written.
+type Scheme interface {
+	// Name returns the name used to select the scheme (e.g. on the command line)
+	Name() string
+	// Parse returns the Version represented by s
+	Parse(s string) (Version, error)
+	// Format returns the string representation of v
+	Format(v Version) string
+	// Compare returns -1, 0 or 1 when a has lower, equal or higher precedence than b
+	Compare(a, b Version) int
+	// Bump returns v with the named field (e.g. "major") incremented
+	Bump(v Version, field string) (Version, error)
+}
+
+// SemVer is the https://semver.org/spec/v2.0.0.html Scheme. Components
+// may be set to use versions with 2 or 4 numeric components instead of 3.
+type SemVer struct {
+	Components int
+}
+
+var (
+	_ Scheme = SemVer{}
+
+	// ErrUnknownField is returned when a Scheme does not know how to bump a field
+	ErrUnknownField = errors.New("unknown field type")
+	// ErrUnknownScheme is returned when no Scheme has the requested name
+	ErrUnknownScheme = errors.New("unknown version scheme")
+	// ErrBuildMetadata is returned when a Scheme can't represent build metadata
+	ErrBuildMetadata = errors.New("build metadata is not supported")
+
+	// componentFields are the names of the numeric components of a Version, in order
+	componentFields = []string{"major", "minor", "patch", "revision"}
+
+	schemes = map[string]Scheme{
+		SemVer{}.Name(): SemVer{},
+		CalVer{}.Name(): mustCalVer(DefaultCalVerLayout),
+		PEP440{}.Name(): PEP440{},
+		Maven{}.Name():  Maven{},
+	}
+)
+
+// DefaultScheme is the Scheme used when none is selected
+var DefaultScheme Scheme = SemVer{}
+
+// LookupScheme returns the Scheme with the given name
+func LookupScheme(name string) (Scheme, error) {
+	if s, ok := schemes[name]; ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("%w %q, try [%s]", ErrUnknownScheme, name, strings.Join(SchemeNames(), ", "))
+}
+
+// SchemeNames returns the names of the known Schemes
+func SchemeNames() []string {
+	names := make([]string, 0, len(schemes))
+	for name := range schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithComponents returns a copy of a Scheme that uses n numeric components,
+// for the schemes that support it (semver, pep440 and maven)
+func WithComponents(s Scheme, n int) (Scheme, error) {
+	if err := checkComponents(n); err != nil {
+		return nil, err
+	}
+	switch scheme := s.(type) {
+	case SemVer:
+		scheme.Components = n
+		return scheme, nil
+	case PEP440:
+		scheme.Components = n
+		return scheme, nil
+	case Maven:
+		scheme.Components = n
+		return scheme, nil
+	}
+	if n == DefaultComponents {
+		return s, nil
+	}
+	return nil, fmt.Errorf("%w: the %s scheme does not support %d components", ErrComponentCount, s.Name(), n)
+}
+
+// CheckBuildMetadata returns an error if a Scheme drops the build metadata of
+// the versions it formats (e.g. maven and calver)
+func CheckBuildMetadata(s Scheme) error {
+	v := Version{Major: 1}
+	withBuild := v
+	withBuild.Build = "build"
+	if s.Format(withBuild) == s.Format(v) {
+		return fmt.Errorf("%w by the %s scheme", ErrBuildMetadata, s.Name())
+	}
+	return nil
+}
+
+// components returns n or DefaultComponents if n is not set
+func components(n int) int {
+	if n == 0 {
+		return DefaultComponents
+	}
+	return n
+}
+
+// bumpComponents returns v with the named field incremented and the fields
+// after it reset to 0, if the field is one of the first n components.
+// The prerelease and build metadata are dropped from the result.
+func bumpComponents(v Version, field string, n int) (Version, error) {
+	i := slices.Index(componentFields, field)
+	switch {
+	case i < 0:
+		return v, ErrUnknownField
+	case i >= n:
+		return v, fmt.Errorf("%w: versions with %d components have no %s", ErrUnknownField, n, field)
+	}
+
+	nums := []*int{&v.Major, &v.Minor, &v.Patch, &v.Revision}
+	*nums[i]++
+	for _, num := range nums[i+1:] {
+		*num = 0
+	}
+	v.PreRelease = ""
+	v.Build = ""
+	return v, nil
+}
+
+// Name implements Scheme.Name()
+func (SemVer) Name() string {
+	return "semver"
+}
+
+// Parse implements Scheme.Parse()
+func (s SemVer) Parse(str string) (Version, error) {
+	return ParseComponents(str, components(s.Components))
+}
+
+// Format implements Scheme.Format()
+func (s SemVer) Format(v Version) string {
+	str := v.formatComponents(components(s.Components))
+	if v.PreRelease != "" {
+		str += "-" + v.PreRelease
+	}
+	if v.Build != "" {
+		str += "+" + v.Build
+	}
+	return str
+}
+
+// Compare implements Scheme.Compare()
+func (SemVer) Compare(a, b Version) int {
+	return Compare(a, b)
+}
+
+// Bump implements Scheme.Bump() for the major, minor, patch and revision
+// fields. The prerelease and build metadata are dropped from the result.
+func (s SemVer) Bump(v Version, field string) (Version, error) {
+	return bumpComponents(v, field, components(s.Components))
+}
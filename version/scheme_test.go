@@ -0,0 +1,141 @@
+package version
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLookupScheme(t *testing.T) {
+	s, err := LookupScheme("semver")
+	if err != nil {
+		t.Fatalf("LookupScheme(%q) error = %v", "semver", err)
+	}
+	if s.Name() != "semver" {
+		t.Errorf("LookupScheme(%q).Name() = %q", "semver", s.Name())
+	}
+
+	if _, err := LookupScheme("foo"); !errors.Is(err, ErrUnknownScheme) {
+		t.Errorf("LookupScheme(%q) error = %v, want %v", "foo", err, ErrUnknownScheme)
+	}
+}
+
+func TestSemVerBump(t *testing.T) {
+	var tests = []struct {
+		input string
+		field string
+		want  string
+	}{
+		{"1.2.3", "major", "2.0.0"},
+		{"1.2.3", "minor", "1.3.0"},
+		{"1.2.3", "patch", "1.2.4"},
+		{"1.3.0-rc.1+build.1", "patch", "1.3.1"},
+		{"1.3.0-rc.1", "major", "2.0.0"},
+	}
+	s := SemVer{}
+	for _, test := range tests {
+		v, err := s.Parse(test.input)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", test.input, err)
+		}
+		bumped, err := s.Bump(v, test.field)
+		if err != nil {
+			t.Errorf("Bump(%v, %q) error = %v", v, test.field, err)
+		}
+		if got := s.Format(bumped); got != test.want {
+			t.Errorf("Bump(%v, %q) = %q, want %q", v, test.field, got, test.want)
+		}
+	}
+
+	if _, err := s.Bump(Version{}, "foobar"); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("Bump(%q) error = %v, want %v", "foobar", err, ErrUnknownField)
+	}
+}
+
+func TestSemVerComponents(t *testing.T) {
+	var tests = []struct {
+		n     int
+		input string
+		field string
+		want  string
+	}{
+		{2, "1.4", "major", "2.0"},
+		{2, "1.4", "minor", "1.5"},
+		{4, "1.4.2.7", "revision", "1.4.2.8"},
+		{4, "1.4.2.7", "patch", "1.4.3.0"},
+		{4, "1.4.2.7-rc.1", "minor", "1.5.0.0"},
+	}
+	for _, test := range tests {
+		s, err := WithComponents(SemVer{}, test.n)
+		if err != nil {
+			t.Fatalf("WithComponents(%d) error = %v", test.n, err)
+		}
+		v, err := s.Parse(test.input)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", test.input, err)
+		}
+		if got := s.Format(v); got != test.input {
+			t.Errorf("Format(Parse(%q)) = %q", test.input, got)
+		}
+		bumped, err := s.Bump(v, test.field)
+		if err != nil {
+			t.Errorf("Bump(%q, %q) error = %v", test.input, test.field, err)
+		}
+		if got := s.Format(bumped); got != test.want {
+			t.Errorf("Bump(%q, %q) = %q, want %q", test.input, test.field, got, test.want)
+		}
+	}
+
+	s, _ := WithComponents(SemVer{}, 2)
+	if _, err := s.Bump(Version{}, "patch"); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("Bump(%q) with 2 components error = %v, want %v", "patch", err, ErrUnknownField)
+	}
+	if _, err := (SemVer{}).Bump(Version{}, "revision"); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("Bump(%q) with 3 components error = %v, want %v", "revision", err, ErrUnknownField)
+	}
+}
+
+func TestWithComponents(t *testing.T) {
+	for _, name := range []string{"semver", "pep440", "maven"} {
+		s, _ := LookupScheme(name)
+		s, err := WithComponents(s, 4)
+		if err != nil {
+			t.Errorf("WithComponents(%s, 4) error = %v", name, err)
+			continue
+		}
+		v, err := s.Parse("1.4.2.7")
+		if err != nil {
+			t.Errorf("%s Parse(%q) error = %v", name, "1.4.2.7", err)
+		}
+		if got := s.Format(v); got != "1.4.2.7" {
+			t.Errorf("%s Format(Parse(%q)) = %q", name, "1.4.2.7", got)
+		}
+	}
+
+	calver, _ := LookupScheme("calver")
+	if _, err := WithComponents(calver, 4); !errors.Is(err, ErrComponentCount) {
+		t.Errorf("WithComponents(calver, 4) error = %v, want %v", err, ErrComponentCount)
+	}
+	if _, err := WithComponents(calver, 3); err != nil {
+		t.Errorf("WithComponents(calver, 3) error = %v", err)
+	}
+	for _, n := range []int{1, 5} {
+		if _, err := WithComponents(SemVer{}, n); !errors.Is(err, ErrComponentCount) {
+			t.Errorf("WithComponents(semver, %d) error = %v, want %v", n, err, ErrComponentCount)
+		}
+	}
+}
+
+func TestCheckBuildMetadata(t *testing.T) {
+	for _, name := range []string{"semver", "pep440"} {
+		s, _ := LookupScheme(name)
+		if err := CheckBuildMetadata(s); err != nil {
+			t.Errorf("CheckBuildMetadata(%s) error = %v", name, err)
+		}
+	}
+	for _, name := range []string{"maven", "calver"} {
+		s, _ := LookupScheme(name)
+		if err := CheckBuildMetadata(s); !errors.Is(err, ErrBuildMetadata) {
+			t.Errorf("CheckBuildMetadata(%s) error = %v, want %v", name, err, ErrBuildMetadata)
+		}
+	}
+}
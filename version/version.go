@@ -1,59 +1,268 @@
 package version
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 )
 
 // A Version is a version of the form <major>.<minor>.<patch>
+// with optional -<prerelease> and +<build> suffixes, as described by
+// https://semver.org/spec/v2.0.0.html
+//
+// Versions with two or four components (<major>.<minor> or
+// <major>.<minor>.<patch>.<revision>) are supported by ParseComponents.
 type Version struct {
 	Major      int
 	Minor      int
 	Patch      int
+	Revision   int
 	PreRelease string
+	Build      string
+}
+
+const (
+	// MinComponents is the smallest supported number of version components
+	MinComponents = 2
+	// MaxComponents is the largest supported number of version components
+	MaxComponents = 4
+	// DefaultComponents is the number of components of a SemVer version
+	DefaultComponents = 3
+)
+
+// A ParseError describes a string that could not be parsed as a Version.
+type ParseError struct {
+	Input string
+	Err   error
+}
+
+var (
+	// ErrComponentCount is returned when the version core does not have the expected components (e.g. X.Y.Z)
+	ErrComponentCount = errors.New("wrong number of version components")
+	// ErrEmptyIdentifier is returned for empty components or identifiers (e.g. "1..2" or "1.2.3-")
+	ErrEmptyIdentifier = errors.New("empty identifier")
+	// ErrLeadingZero is returned for numeric identifiers with leading zeros (e.g. "01")
+	ErrLeadingZero = errors.New("numeric identifier has a leading zero")
+	// ErrInvalidCharacter is returned for characters outside of [0-9A-Za-z-]
+	ErrInvalidCharacter = errors.New("invalid character")
+	// ErrInvalidNumber is returned for components that are not non-negative integers
+	ErrInvalidNumber = errors.New("invalid number")
+)
+
+// Error implements error
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parsing %s as a version: %v", e.Input, e.Err)
+}
+
+// Unwrap returns the underlying cause so that errors.Is can match the Err* values
+func (e *ParseError) Unwrap() error {
+	return e.Err
 }
 
 // FromString returns a Version based on a string
 func FromString(v string) (ver Version, err error) {
-	releases := strings.Split(v, "-")
-	components := strings.Split(releases[0], ".")
+	return ParseComponents(v, DefaultComponents)
+}
 
-	if len(components) != 3 {
-		return ver, fmt.Errorf("Version must contain 3 components: X.Y.Z")
+// ParseComponents returns a Version based on a string with n components,
+// where n is between MinComponents and MaxComponents
+func ParseComponents(v string, n int) (ver Version, err error) {
+	parsed, err := parse(v, n)
+	if err != nil {
+		return ver, &ParseError{Input: v, Err: err}
 	}
+	return parsed, nil
+}
 
-	maj, err := strconv.Atoi(components[0])
-	if err != nil {
-		return ver, fmt.Errorf("parsing %s as a version: %v", v, err)
+func parse(v string, n int) (ver Version, err error) {
+	if err = checkComponents(n); err != nil {
+		return ver, err
 	}
 
-	min, err := strconv.Atoi(components[1])
-	if err != nil {
-		return ver, fmt.Errorf("parsing %s as a version: %v", v, err)
+	core, build, hasBuild := strings.Cut(v, "+")
+	core, pre, hasPre := strings.Cut(core, "-")
+
+	components := strings.Split(core, ".")
+	if len(components) != n {
+		return ver, fmt.Errorf("%w: expected %s", ErrComponentCount, componentLayout(n))
 	}
 
-	patch, err := strconv.Atoi(components[2])
-	if err != nil {
-		return ver, fmt.Errorf("parsing %s as a version: %v", v, err)
+	nums := make([]int, MaxComponents)
+	for i, c := range components {
+		if nums[i], err = parseNumeric(c); err != nil {
+			return ver, err
+		}
+	}
+
+	if hasPre {
+		if err = validateIdentifiers(pre, true); err != nil {
+			return ver, fmt.Errorf("prerelease: %w", err)
+		}
+	}
+	if hasBuild {
+		if err = validateIdentifiers(build, false); err != nil {
+			return ver, fmt.Errorf("build metadata: %w", err)
+		}
+	}
+
+	return Version{nums[0], nums[1], nums[2], nums[3], pre, build}, nil
+}
+
+// checkComponents returns an error if n is not a supported number of components
+func checkComponents(n int) error {
+	if n < MinComponents || n > MaxComponents {
+		return fmt.Errorf("%w: %d is not between %d and %d", ErrComponentCount, n, MinComponents, MaxComponents)
+	}
+	return nil
+}
+
+// componentLayout describes n components, e.g. X.Y.Z for 3
+func componentLayout(n int) string {
+	return strings.Join([]string{"X", "Y", "Z", "W"}[:n], ".")
+}
+
+// components returns the first n numeric components of v
+func (v Version) components(n int) []int {
+	return []int{v.Major, v.Minor, v.Patch, v.Revision}[:n]
+}
+
+// formatComponents formats the first n numeric components of v, e.g. 1.2.3 for 3
+func (v Version) formatComponents(n int) string {
+	parts := make([]string, n)
+	for i, c := range v.components(n) {
+		parts[i] = strconv.Itoa(c)
 	}
+	return strings.Join(parts, ".")
+}
 
-	prerelease := ""
-	if len(releases) == 2 {
-		prerelease = releases[1]
+// parseNumeric parses a numeric identifier, which may not have leading zeros
+func parseNumeric(s string) (int, error) {
+	if s == "" {
+		return 0, ErrEmptyIdentifier
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidNumber, s)
+		}
+	}
+	if len(s) > 1 && s[0] == '0' {
+		return 0, fmt.Errorf("%w: %q", ErrLeadingZero, s)
 	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidNumber, s)
+	}
+	return n, nil
+}
 
-	return Version{maj, min, patch, prerelease}, nil
+// validateIdentifiers checks a dot-separated list of identifiers. Numeric
+// identifiers with leading zeros are rejected when rejectLeadingZero is set.
+func validateIdentifiers(s string, rejectLeadingZero bool) error {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return ErrEmptyIdentifier
+		}
+		numeric := true
+		for _, r := range id {
+			switch {
+			case r >= '0' && r <= '9':
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-':
+				numeric = false
+			default:
+				return fmt.Errorf("%w %q in %q", ErrInvalidCharacter, r, id)
+			}
+		}
+		if rejectLeadingZero && numeric && len(id) > 1 && id[0] == '0' {
+			return fmt.Errorf("%w: %q", ErrLeadingZero, id)
+		}
+	}
+	return nil
 }
 
-// String formats Version as <major>.<minor>.<patch>
-// or <major>.<minor>.<patch>-<prerelease>
+// String formats Version as <major>.<minor>.<patch>, followed by
+// .<revision> when it is not 0, and -<prerelease> and +<build> when they are set
 func (v Version) String() string {
+	n := DefaultComponents
+	if v.Revision != 0 {
+		n = MaxComponents
+	}
+	s := v.formatComponents(n)
 	if v.PreRelease != "" {
-		return fmt.Sprintf("%d.%d.%d-%s", v.Major, v.Minor, v.Patch, v.PreRelease)
+		s += "-" + v.PreRelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 when a has lower, equal or higher precedence
+// than b, following https://semver.org/spec/v2.0.0.html#spec-item-11.
+// Build metadata is ignored.
+func Compare(a, b Version) int {
+	if c := cmp.Compare(a.Major, b.Major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Minor, b.Minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Patch, b.Patch); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Revision, b.Revision); c != 0 {
+		return c
+	}
+	return comparePreRelease(a.PreRelease, b.PreRelease)
+}
+
+// comparePreRelease compares dot-separated prerelease identifiers; a version
+// without a prerelease has higher precedence than one with a prerelease
+func comparePreRelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
 	}
 
-	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareIdentifier(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(as), len(bs))
+}
+
+// compareIdentifier compares numeric identifiers numerically and others
+// lexically; numeric identifiers have lower precedence than alphanumeric ones
+func compareIdentifier(a, b string) int {
+	an, bn := isNumeric(a), isNumeric(b)
+	switch {
+	case an && bn:
+		// Numeric identifiers have no leading zeros, so the longer one is larger
+		if c := cmp.Compare(len(a), len(b)); c != 0 {
+			return c
+		}
+	case an:
+		return -1
+	case bn:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func isNumeric(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
 }
 
 // List is a slice of Versions that implements sort.Interface
@@ -66,19 +275,7 @@ func (v List) Len() int {
 
 // Less implements sort.Interface.Less()
 func (v List) Less(i, j int) bool {
-	if v[i].Major != v[j].Major {
-		return v[i].Major < v[j].Major
-	}
-	if v[i].Minor != v[j].Minor {
-		return v[i].Minor < v[j].Minor
-	}
-	if v[i].Patch != v[j].Patch {
-		return v[i].Patch < v[j].Patch
-	}
-	if v[i].PreRelease != v[j].PreRelease {
-		return v[i].PreRelease < v[j].PreRelease
-	}
-	return false
+	return Compare(v[i], v[j]) < 0
 }
 
 // Swap implements sort.Interface.Swap()
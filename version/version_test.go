@@ -1,6 +1,7 @@
 package version
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"testing"
@@ -11,13 +12,20 @@ func TestFromString(t *testing.T) {
 		input string
 		want  Version
 	}{
-		{"1.2.3", Version{1, 2, 3, ""}},
-		{"3.2.1", Version{3, 2, 1, ""}},
-		{"a.b.c", Version{0, 0, 0, ""}},
-		{"1.b.c", Version{0, 0, 0, ""}},
-		{"1.2.c", Version{0, 0, 0, ""}},
-		{"1.2.3.4", Version{0, 0, 0, ""}},
-		{"3.2.1-abc", Version{3, 2, 1, "abc"}},
+		{"1.2.3", Version{1, 2, 3, 0, "", ""}},
+		{"3.2.1", Version{3, 2, 1, 0, "", ""}},
+		{"a.b.c", Version{0, 0, 0, 0, "", ""}},
+		{"1.b.c", Version{0, 0, 0, 0, "", ""}},
+		{"1.2.c", Version{0, 0, 0, 0, "", ""}},
+		{"1.2.3.4", Version{0, 0, 0, 0, "", ""}},
+		{"3.2.1-abc", Version{3, 2, 1, 0, "abc", ""}},
+		{"1.2.3-rc-1", Version{1, 2, 3, 0, "rc-1", ""}},
+		{"1.2.3-rc.1", Version{1, 2, 3, 0, "rc.1", ""}},
+		{"1.2.3+build.5", Version{1, 2, 3, 0, "", "build.5"}},
+		{"1.2.3-rc.1+build.05", Version{1, 2, 3, 0, "rc.1", "build.05"}},
+		{"1.2.3-0.3.7", Version{1, 2, 3, 0, "0.3.7", ""}},
+		{"01.2.3", Version{0, 0, 0, 0, "", ""}},
+		{"1.2.3-rc.01", Version{0, 0, 0, 0, "", ""}},
 	}
 	for _, test := range tests {
 		if v, _ := FromString(test.input); v != test.want {
@@ -34,6 +42,73 @@ func TestBadString(t *testing.T) {
 	}
 }
 
+func TestFromStringErrors(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  error
+	}{
+		{"1.2", ErrComponentCount},
+		{"1.2.3.4", ErrComponentCount},
+		{"1..3", ErrEmptyIdentifier},
+		{"1.2.3-", ErrEmptyIdentifier},
+		{"1.2.3-rc..1", ErrEmptyIdentifier},
+		{"1.2.3+", ErrEmptyIdentifier},
+		{"01.2.3", ErrLeadingZero},
+		{"1.2.03", ErrLeadingZero},
+		{"1.2.3-rc.01", ErrLeadingZero},
+		{"1.b.3", ErrInvalidNumber},
+		{"1.2.3-rc_1", ErrInvalidCharacter},
+		{"1.2.3+build+1", ErrInvalidCharacter},
+	}
+	for _, test := range tests {
+		_, err := FromString(test.input)
+		if !errors.Is(err, test.want) {
+			t.Errorf("FromString(%q) error = %v, want %v", test.input, err, test.want)
+		}
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.Input != test.input {
+			t.Errorf("FromString(%q) error = %#v, want a *ParseError", test.input, err)
+		}
+	}
+}
+
+func TestParseComponents(t *testing.T) {
+	var tests = []struct {
+		input string
+		n     int
+		want  Version
+	}{
+		{"1.4", 2, Version{1, 4, 0, 0, "", ""}},
+		{"1.4-rc.1", 2, Version{1, 4, 0, 0, "rc.1", ""}},
+		{"1.4.2.7", 4, Version{1, 4, 2, 7, "", ""}},
+		{"1.4.2.7+build.1", 4, Version{1, 4, 2, 7, "", "build.1"}},
+	}
+	for _, test := range tests {
+		v, err := ParseComponents(test.input, test.n)
+		if err != nil {
+			t.Errorf("ParseComponents(%q, %d) error = %v", test.input, test.n, err)
+		}
+		if v != test.want {
+			t.Errorf("ParseComponents(%q, %d) = %#v, want %#v", test.input, test.n, v, test.want)
+		}
+	}
+
+	for _, test := range []struct {
+		input string
+		n     int
+	}{
+		{"1.4", 3},
+		{"1.4.2", 2},
+		{"1.4.2", 4},
+		{"1", 1},
+		{"1.2.3.4.5", 5},
+	} {
+		if _, err := ParseComponents(test.input, test.n); !errors.Is(err, ErrComponentCount) {
+			t.Errorf("ParseComponents(%q, %d) error = %v, want %v", test.input, test.n, err, ErrComponentCount)
+		}
+	}
+}
+
 func TestToString(t *testing.T) {
 	want := "1.2.3"
 	v, _ := FromString(want)
@@ -52,24 +127,41 @@ func TestToStringPre(t *testing.T) {
 	}
 }
 
+func TestRoundTrip(t *testing.T) {
+	for _, want := range []string{
+		"1.2.3-rc-1",
+		"1.2.3-rc.1+build.5",
+		"1.2.3+exp.sha.5114f85",
+		"1.0.0-x-y-z.--",
+	} {
+		v, err := FromString(want)
+		if err != nil {
+			t.Errorf("FromString(%q) error = %v", want, err)
+		}
+		if got := v.String(); got != want {
+			t.Errorf(`FromString(%q).String() == %q`, want, got)
+		}
+	}
+}
+
 func TestVersionListSort(t *testing.T) {
 	var versions = List{
-		{2, 1, 3, ""},
-		{1, 2, 3, ""},
-		{2, 2, 3, ""},
-		{3, 1, 2, ""},
-		{1, 2, 2, ""},
-		{1, 2, 3, ""},
-		{1, 2, 3, "abc"},
+		{2, 1, 3, 0, "", ""},
+		{1, 2, 3, 0, "", ""},
+		{2, 2, 3, 0, "", ""},
+		{3, 1, 2, 0, "", ""},
+		{1, 2, 2, 0, "", ""},
+		{1, 2, 3, 0, "", ""},
+		{1, 2, 3, 0, "abc", ""},
 	}
 	var want = List{
-		{1, 2, 2, ""},
-		{1, 2, 3, ""},
-		{1, 2, 3, ""},
-		{1, 2, 3, "abc"},
-		{2, 1, 3, ""},
-		{2, 2, 3, ""},
-		{3, 1, 2, ""},
+		{1, 2, 2, 0, "", ""},
+		{1, 2, 3, 0, "abc", ""},
+		{1, 2, 3, 0, "", ""},
+		{1, 2, 3, 0, "", ""},
+		{2, 1, 3, 0, "", ""},
+		{2, 2, 3, 0, "", ""},
+		{3, 1, 2, 0, "", ""},
 	}
 
 	sort.Sort(versions)
@@ -82,3 +174,60 @@ func TestVersionListSort(t *testing.T) {
 		}
 	}
 }
+
+func TestCompare(t *testing.T) {
+	// In ascending order of precedence, per https://semver.org/spec/v2.0.0.html#spec-item-11
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0-rc.2",
+		"1.0.0-rc.10",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := FromString(ordered[i])
+			b, _ := FromString(ordered[j])
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := Compare(a, b); got != want {
+				t.Errorf("Compare(%v, %v) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+}
+
+func TestCompareRevision(t *testing.T) {
+	a, _ := ParseComponents("1.2.3.4", 4)
+	b, _ := ParseComponents("1.2.3.10", 4)
+	c, _ := ParseComponents("1.2.4.0", 4)
+	if got := Compare(a, b); got != -1 {
+		t.Errorf("Compare(%v, %v) = %d, want -1", a, b, got)
+	}
+	if got := Compare(b, c); got != -1 {
+		t.Errorf("Compare(%v, %v) = %d, want -1", b, c, got)
+	}
+	if got := a.String(); got != "1.2.3.4" {
+		t.Errorf("String() = %q, want %q", got, "1.2.3.4")
+	}
+}
+
+func TestCompareIgnoresBuild(t *testing.T) {
+	a, _ := FromString("1.2.3-rc.1+build.1")
+	b, _ := FromString("1.2.3-rc.1+build.2")
+	if got := Compare(a, b); got != 0 {
+		t.Errorf("Compare(%v, %v) = %d, want 0", a, b, got)
+	}
+}